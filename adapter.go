@@ -16,13 +16,17 @@ package redisadapter
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/casbin/casbin/v2/model"
 	"github.com/casbin/casbin/v2/persist"
@@ -40,47 +44,151 @@ type CasbinRule struct {
 	V5    string
 }
 
+// defaultMaxIdle is the number of idle connections kept by the pool that the
+// adapter creates when it is not given one.
+const defaultMaxIdle = 10
+
 // Adapter represents the Redis adapter for policy storage.
+// It is safe for concurrent use by multiple goroutines.
 type Adapter struct {
-	network    string
-	address    string
-	key        string
-	username   string
-	password   string
-	tlsConfig  *tls.Config
-	_conn      redis.Conn
-	_pool      *redis.Pool
-	isFiltered bool
+	network      string
+	address      string
+	key          string
+	username     string
+	password     string
+	tlsConfig    *tls.Config
+	_pool        *redis.Pool
+	ownsPool     bool
+	useFunctions bool
+	fcall        bool
+	metrics      Metrics
+	tracer       Tracer
+
+	logger               *slog.Logger
+	slowCommandThreshold time.Duration
+
+	lenientLoad          bool
+	malformedRuleHandler func(*MalformedRuleError)
+
+	batchSize     int
+	uncheckedAdds bool
+	writeBehind   *writeBehind
+	cache         *policyCache
+	revision      bool
+
+	maxRetries      int
+	minRetryBackoff time.Duration
+	maxRetryBackoff time.Duration
+
+	connectTimeout  time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	keepAlive       time.Duration
+	maxIdle         int
+	maxActive       int
+	idleTimeout     time.Duration
+	maxConnLifetime time.Duration
+
+	mu            sync.RWMutex
+	isFiltered    bool
+	loadedFilters []*Filter
+	closing       bool
+	closed        bool
 }
 
+// ErrAdapterClosed is returned by the operations of an adapter after Close.
+var ErrAdapterClosed = errors.New("adapter is closed")
+
 func (a *Adapter) getConn() redis.Conn {
-	if a._pool != nil {
-		return a._pool.Get()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return errorConn{ErrAdapterClosed}
 	}
-	return a._conn
+	return a.wrapConn(a._pool.Get())
 }
 
 func (a *Adapter) release(conn redis.Conn) {
-	if a._pool != nil {
-		if conn != nil {
-			conn.Close()
-		}
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// errorConn is the connection handed out by a closed adapter.
+type errorConn struct{ err error }
+
+func (c errorConn) Close() error                                   { return nil }
+func (c errorConn) Err() error                                     { return c.err }
+func (c errorConn) Do(string, ...interface{}) (interface{}, error) { return nil, c.err }
+func (c errorConn) Send(string, ...interface{}) error              { return c.err }
+func (c errorConn) Flush() error                                   { return c.err }
+func (c errorConn) Receive() (interface{}, error)                  { return nil, c.err }
+func (c errorConn) DoContext(context.Context, string, ...interface{}) (interface{}, error) {
+	return nil, c.err
+}
+
+// setUnfiltered records that the whole policy was loaded.
+func (a *Adapter) setUnfiltered() {
+	a.mu.Lock()
+	a.isFiltered = false
+	a.loadedFilters = nil
+	a.mu.Unlock()
+}
+
+func newDefaultAdapter() *Adapter {
+	return &Adapter{
+		maxRetries:      defaultMaxRetries,
+		minRetryBackoff: defaultMinRetryBackoff,
+		maxRetryBackoff: defaultMaxRetryBackoff,
+		maxIdle:         defaultMaxIdle,
+		batchSize:       defaultBatchSize,
 	}
 }
 
 // finalizer is the destructor for Adapter.
 func finalizer(a *Adapter) {
-	if a._conn != nil {
-		a._conn.Close()
+	_ = a.Close()
+}
+
+// Close releases the resources of the adapter. The connection pool is only
+// closed if the adapter created it, a pool given to NewAdapterWithPool or
+// NewAdapterWithPoolAndOptions remains owned by the caller. All operations
+// on a closed adapter fail with ErrAdapterClosed.
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	if a.closing {
+		a.mu.Unlock()
+		return nil
+	}
+	// No changes are queued from now on, and the queued ones are written
+	// while the connections can still be used.
+	a.closing = true
+	a.mu.Unlock()
+
+	var err error
+	if a.writeBehind != nil {
+		err = a.writeBehind.close(a)
+	}
+	if a.cache != nil {
+		a.cache.close()
 	}
-	if a._pool != nil {
-		a._pool.Close()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	runtime.SetFinalizer(a, nil)
+
+	if a.ownsPool && a._pool != nil {
+		if cerr := a._pool.Close(); cerr != nil {
+			return cerr
+		}
 	}
+	return err
 }
 
 func newAdapter(network string, address string, key string,
 	username string, password string) (*Adapter, error) {
-	a := &Adapter{}
+	a := newDefaultAdapter()
 	a.network = network
 	a.address = address
 	a.key = key
@@ -89,6 +197,9 @@ func newAdapter(network string, address string, key string,
 
 	// Open the DB, create it if not existed.
 	err := a.open()
+	if err == nil {
+		err = a.loadScripts()
+	}
 
 	// Call the destructor when the object is released.
 	runtime.SetFinalizer(a, finalizer)
@@ -117,50 +228,62 @@ func NewAdapterWithKey(network string, address string, key string) (*Adapter, er
 
 // NewAdapterWithPool is the constructor for Adapter.
 func NewAdapterWithPool(pool *redis.Pool) (*Adapter, error) {
-	a := &Adapter{}
+	a := newDefaultAdapter()
 	a.key = "casbin_rules"
-
-	conn := pool.Get()
-	defer a.release(conn)
-
-	a._conn = conn
 	a._pool = pool
 
 	// Call the destructor when the object is released.
 	runtime.SetFinalizer(a, finalizer)
 
-	return a, nil
+	return a, a.loadScripts()
 }
 
 // NewAdapterWithPoolAndOptions is the constructor for Adapter.
 func NewAdapterWithPoolAndOptions(pool *redis.Pool, options ...Option) (*Adapter, error) {
-	a := &Adapter{}
+	a := newDefaultAdapter()
 	a.key = "casbin_rules"
 	for _, option := range options {
 		option(a)
 	}
-
-	conn := pool.Get()
-	defer a.release(conn)
-
-	a._conn = conn
 	a._pool = pool
 
+	err := a.loadScripts()
+	if err == nil {
+		a.startWorkers()
+	}
+
 	// Call the destructor when the object is released.
 	runtime.SetFinalizer(a, finalizer)
 
-	return a, nil
+	return a, err
 }
 
 type Option func(*Adapter)
 
+// startWorkers starts the goroutines of the write-behind mode and of the
+// cache, once the adapter was created successfully.
+func (a *Adapter) startWorkers() {
+	if a.writeBehind != nil {
+		a.writeBehind.start(a)
+	}
+	if a.cache != nil {
+		a.cache.start(a)
+	}
+}
+
 func NewAdapterWithOption(options ...Option) (*Adapter, error) {
-	a := &Adapter{}
+	a := newDefaultAdapter()
 	for _, option := range options {
 		option(a)
 	}
 	// Open the DB, create it if not existed.
 	err := a.open()
+	if err == nil {
+		err = a.loadScripts()
+	}
+	if err == nil {
+		a.startWorkers()
+	}
 
 	// Call the destructor when the object is released.
 	runtime.SetFinalizer(a, finalizer)
@@ -203,41 +326,122 @@ func WithTls(tlsConfig *tls.Config) Option {
 	}
 }
 
-func (a *Adapter) open() error {
-	//redis.Dial("tcp", "127.0.0.1:6379")
-	useTls := a.tlsConfig != nil
-	if a.username != "" {
-		conn, err := redis.Dial(a.network, a.address, redis.DialUsername(a.username), redis.DialPassword(a.password), redis.DialTLSConfig(a.tlsConfig), redis.DialUseTLS(useTls))
-		if err != nil {
-			return err
-		}
+// The following options configure the connections and the pool that the
+// adapter creates itself. They have no effect on a pool given to
+// NewAdapterWithPoolAndOptions.
 
-		a._conn = conn
-	} else if a.password == "" {
-		conn, err := redis.Dial(a.network, a.address, redis.DialTLSConfig(a.tlsConfig), redis.DialUseTLS(useTls))
-		if err != nil {
-			return err
-		}
+// WithConnectTimeout sets the timeout for connecting to the server.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(a *Adapter) {
+		a.connectTimeout = timeout
+	}
+}
 
-		a._conn = conn
-	} else {
-		conn, err := redis.Dial(a.network, a.address, redis.DialPassword(a.password), redis.DialTLSConfig(a.tlsConfig), redis.DialUseTLS(useTls))
-		if err != nil {
-			return err
-		}
+// WithReadTimeout sets the timeout for reading a single command reply.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(a *Adapter) {
+		a.readTimeout = timeout
+	}
+}
 
-		a._conn = conn
+// WithWriteTimeout sets the timeout for writing a single command.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(a *Adapter) {
+		a.writeTimeout = timeout
+	}
+}
+
+// WithKeepAlive sets the period of the TCP keep-alive probes.
+func WithKeepAlive(period time.Duration) Option {
+	return func(a *Adapter) {
+		a.keepAlive = period
+	}
+}
+
+// WithMaxIdle sets the maximum number of idle connections in the pool.
+func WithMaxIdle(maxIdle int) Option {
+	return func(a *Adapter) {
+		a.maxIdle = maxIdle
+	}
+}
+
+// WithMaxActive sets the maximum number of connections opened at the same
+// time. Once it is reached, operations wait for a connection to be released.
+// Zero means no limit.
+func WithMaxActive(maxActive int) Option {
+	return func(a *Adapter) {
+		a.maxActive = maxActive
+	}
+}
+
+// WithIdleTimeout closes connections that stayed idle for longer than timeout.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(a *Adapter) {
+		a.idleTimeout = timeout
+	}
+}
+
+// WithUncheckedAdds makes AddPolicy and AddPolicies push the rules without
+// checking whether they are stored, which takes O(1) time on the server
+// instead of scanning the whole list. Adding a stored rule again then stores a
+// duplicate that Deduplicate removes. AddPolicyEx and AddPoliciesEx still
+// check the stored rules.
+func WithUncheckedAdds() Option {
+	return func(a *Adapter) {
+		a.uncheckedAdds = true
 	}
-	return nil
 }
 
-func (a *Adapter) close() {
-	if a._conn != nil {
-		a._conn.Close()
+// WithMaxConnLifetime closes connections older than lifetime.
+func WithMaxConnLifetime(lifetime time.Duration) Option {
+	return func(a *Adapter) {
+		a.maxConnLifetime = lifetime
+	}
+}
+
+func (a *Adapter) dial() (redis.Conn, error) {
+	useTls := a.tlsConfig != nil
+	options := []redis.DialOption{redis.DialTLSConfig(a.tlsConfig), redis.DialUseTLS(useTls)}
+	if a.username != "" {
+		options = append(options, redis.DialUsername(a.username))
+	}
+	if a.password != "" {
+		options = append(options, redis.DialPassword(a.password))
+	}
+	if a.connectTimeout > 0 {
+		options = append(options, redis.DialConnectTimeout(a.connectTimeout))
+	}
+	if a.readTimeout > 0 {
+		options = append(options, redis.DialReadTimeout(a.readTimeout))
+	}
+	if a.writeTimeout > 0 {
+		options = append(options, redis.DialWriteTimeout(a.writeTimeout))
 	}
-	if a._pool != nil {
-		a._pool.Close()
+	if a.keepAlive > 0 {
+		options = append(options, redis.DialKeepAlive(a.keepAlive))
 	}
+	return redis.Dial(a.network, a.address, options...)
+}
+
+// open creates the connection pool of the adapter and checks that the server
+// can be reached. A single redis.Conn must not be shared by goroutines, so
+// even the adapters that are not given a pool use one internally.
+func (a *Adapter) open() error {
+	a._pool = &redis.Pool{
+		Dial:            a.dial,
+		TestOnBorrow:    testOnBorrow,
+		MaxIdle:         a.maxIdle,
+		MaxActive:       a.maxActive,
+		IdleTimeout:     a.idleTimeout,
+		MaxConnLifetime: a.maxConnLifetime,
+		Wait:            a.maxActive > 0,
+	}
+	a.ownsPool = true
+
+	conn := a._pool.Get()
+	defer a.release(conn)
+
+	return conn.Err()
 }
 
 func (a *Adapter) createTable() {
@@ -276,53 +480,129 @@ func (c *CasbinRule) toStringPolicy() []string {
 	return policy
 }
 
-func loadPolicyLine(line CasbinRule, model model.Model) {
+// rangeRules returns all stored rules.
+func (a *Adapter) rangeRules() ([]interface{}, error) {
+	var values []interface{}
+	err := a.retry(func() error {
+		conn := a.getConn()
+		defer a.release(conn)
+
+		var err error
+		values, err = redis.Values(conn.Do("LRANGE", a.key, 0, -1))
+		return err
+	})
+	return values, err
+}
+
+func valueToBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		// Amazon MemoryDB for Redis returns string instead of []byte
+		return []byte(v), nil
+	default:
+		return nil, errors.New("the type is wrong")
+	}
+}
+
+// loadPolicyLine loads a decoded rule into the model, or returns an error if
+// its ptype is missing or not defined by the model.
+func loadPolicyLine(line CasbinRule, model model.Model) error {
+	if line.PType == "" {
+		return errors.New("missing ptype")
+	}
+	sec := line.PType[:1]
+	if err := checkSection(sec, line.PType); err != nil {
+		return err
+	}
+	if _, ok := model[sec][line.PType]; !ok {
+		return fmt.Errorf("ptype %q is not defined by the model", line.PType)
+	}
 	text := line.toStringPolicy()
 
 	persist.LoadPolicyArray(text, model)
+	return nil
 }
 
 // LoadPolicy loads policy from database.
-func (a *Adapter) LoadPolicy(model model.Model) error {
-	conn := a.getConn()
-	defer a.release(conn)
+func (a *Adapter) LoadPolicy(model model.Model) (err error) {
+	op := a.startOperation("LoadPolicy")
+	defer func() { op.end(err) }()
 
-	num, err := redis.Int(conn.Do("LLEN", a.key))
-	if err == redis.ErrNil {
-		return nil
-	}
-	if err != nil {
-		return err
+	return a.loadPolicy(op, model)
+}
+
+func (a *Adapter) loadPolicy(op *operation, model model.Model) error {
+	var skipped []*MalformedRuleError
+	if a.cache != nil {
+		rules, err := a.cache.load(op, a)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			err = rule.err
+			if err == nil {
+				err = loadPolicyLine(rule.line, model)
+			}
+			if err != nil {
+				if err = op.skipMalformedRule(&skipped, rule.index, rule.value, err); err != nil {
+					return err
+				}
+			}
+		}
+		a.setUnfiltered()
+		return skippedRulesError(skipped)
 	}
-	values, err := redis.Values(conn.Do("LRANGE", a.key, 0, num))
+
+	values, err := a.rangeRules()
 	if err != nil {
 		return err
 	}
 
-	var line CasbinRule
-	for _, value := range values {
-		text, ok := value.([]byte)
-		if !ok {
-			// Amazon MemoryDB for Redis returns string instead of []byte
-			if textStr, ok := value.(string); ok {
-				text = []byte(textStr)
-			} else {
-				return errors.New("the type is wrong")
+	for i, value := range values {
+		text, err := valueToBytes(value)
+		if err != nil {
+			if err = op.skipMalformedRule(&skipped, i, value, err); err != nil {
+				return err
 			}
+			continue
 		}
+		op.addRead(1, len(text))
+		var line CasbinRule
 		err = json.Unmarshal(text, &line)
+		if err == nil {
+			err = loadPolicyLine(line, model)
+		}
 		if err != nil {
-			return err
+			if err = op.skipMalformedRule(&skipped, i, value, err); err != nil {
+				return err
+			}
 		}
-		loadPolicyLine(line, model)
 	}
 
-	a.isFiltered = false
+	a.setUnfiltered()
+	return skippedRulesError(skipped)
+}
+
+// checkSection returns an error if sec is not a policy section or ptype does
+// not belong to it. Casbin assigns a loaded rule to the section named by the
+// first letter of its ptype, so any other combination cannot be read back.
+func checkSection(sec string, ptype string) error {
+	if sec != "p" && sec != "g" {
+		return fmt.Errorf("unknown section: %q", sec)
+	}
+	if !strings.HasPrefix(ptype, sec) {
+		return fmt.Errorf("ptype %q does not belong to section %q", ptype, sec)
+	}
 	return nil
 }
 
-func savePolicyLine(ptype string, rule []string) CasbinRule {
+func savePolicyLine(sec string, ptype string, rule []string) (CasbinRule, error) {
 	line := CasbinRule{}
+	if err := checkSection(sec, ptype); err != nil {
+		return line, err
+	}
 
 	line.PType = ptype
 	if len(rule) > 0 {
@@ -344,117 +624,273 @@ func savePolicyLine(ptype string, rule []string) CasbinRule {
 		line.V5 = rule[5]
 	}
 
-	return line
+	return line, nil
 }
 
 // SavePolicy saves policy to database.
-func (a *Adapter) SavePolicy(model model.Model) error {
-	a.dropTable()
-	a.createTable()
+// Only the rules that differ from the stored ones are written.
+func (a *Adapter) SavePolicy(model model.Model) (err error) {
+	op := a.startOperation("SavePolicy")
+	defer func() { op.end(err) }()
+	a.beginWrite(op)
 
-	var texts [][]byte
+	_, err = a.savePolicy(op, model)
+	return err
+}
 
-	for ptype, ast := range model["p"] {
-		for _, rule := range ast.Policy {
-			line := savePolicyLine(ptype, rule)
-			text, err := json.Marshal(line)
-			if err != nil {
-				return err
-			}
-			texts = append(texts, text)
-		}
+// AddPolicy adds a policy rule to the storage.
+// Adding a rule that is already stored is a no-op, unless the adapter was
+// created with WithUncheckedAdds.
+func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) (err error) {
+	op := a.startOperation("AddPolicy")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+
+	texts, err := encodeRules(sec, ptype, [][]string{rule})
+	if err != nil {
+		return err
 	}
-
-	for ptype, ast := range model["g"] {
-		for _, rule := range ast.Policy {
-			line := savePolicyLine(ptype, rule)
-			text, err := json.Marshal(line)
-			if err != nil {
-				return err
-			}
-			texts = append(texts, text)
-		}
+	if a.writeBehind != nil {
+		return a.writeBehind.queue(a, sec, ptype, [][]string{rule}, texts, false)
 	}
+	a.beginWrite(op)
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err := conn.Do("RPUSH", redis.Args{}.Add(a.key).AddFlat(texts)...)
-	return err
+	return a.writeTexts(op, conn, texts)
 }
 
-// AddPolicy adds a policy rule to the storage.
-func (a *Adapter) AddPolicy(sec string, ptype string, rule []string) error {
-	line := savePolicyLine(ptype, rule)
-	text, err := json.Marshal(line)
+// AddPolicyEx adds a policy rule to the storage unless it is already stored,
+// and reports whether the rule was newly inserted.
+func (a *Adapter) AddPolicyEx(sec string, ptype string, rule []string) (_ bool, err error) {
+	op := a.startOperation("AddPolicyEx")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
+
+	added, err := a.addPolicies(op, sec, ptype, [][]string{rule})
+	if err != nil {
+		return false, err
+	}
+	return added[0], nil
+}
+
+// RemovePolicy removes a policy rule from the storage.
+func (a *Adapter) RemovePolicy(sec string, ptype string, rule []string) (err error) {
+	op := a.startOperation("RemovePolicy")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+
+	texts, err := encodeRules(sec, ptype, [][]string{rule})
 	if err != nil {
 		return err
 	}
+	if a.writeBehind != nil {
+		return a.writeBehind.queue(a, sec, ptype, [][]string{rule}, texts, true)
+	}
+	a.beginWrite(op)
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err = conn.Do("RPUSH", a.key, text)
-	return err
+	return a.removeTexts(op, conn, texts)
 }
 
-// RemovePolicy removes a policy rule from the storage.
-func (a *Adapter) RemovePolicy(sec string, ptype string, rule []string) error {
-	line := savePolicyLine(ptype, rule)
-	text, err := json.Marshal(line)
-	if err != nil {
+// AddPolicies adds policy rules to the storage.
+// Rules that are already stored are skipped, unless the adapter was created
+// with WithUncheckedAdds.
+func (a *Adapter) AddPolicies(sec string, ptype string, rules [][]string) (err error) {
+	op := a.startOperation("AddPolicies")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+
+	texts, err := encodeRules(sec, ptype, rules)
+	if err != nil || len(texts) == 0 {
 		return err
 	}
+	if a.writeBehind != nil {
+		return a.writeBehind.queue(a, sec, ptype, rules, texts, false)
+	}
+	a.beginWrite(op)
+
+	conn := a.getConn()
+	defer a.release(conn)
+
+	return a.writeTexts(op, conn, texts)
+}
+
+// AddPoliciesEx adds the policy rules that are not stored yet, and reports for
+// each rule whether it was newly inserted.
+func (a *Adapter) AddPoliciesEx(sec string, ptype string, rules [][]string) (_ []bool, err error) {
+	op := a.startOperation("AddPoliciesEx")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
+
+	return a.addPolicies(op, sec, ptype, rules)
+}
+
+func (a *Adapter) addPolicies(op *operation, sec string, ptype string, rules [][]string) ([]bool, error) {
+	texts, err := encodeRules(sec, ptype, rules)
+	if err != nil {
+		return nil, err
+	}
+	if len(texts) == 0 {
+		return []bool{}, nil
+	}
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err = conn.Do("LREM", a.key, 1, text)
+	return a.addTexts(op, conn, texts)
+}
+
+// writeTexts adds the encoded rules, without checking whether they are
+// stored if the adapter was created with WithUncheckedAdds.
+func (a *Adapter) writeTexts(op *operation, conn redis.Conn, texts [][]byte) error {
+	if a.uncheckedAdds {
+		return a.appendTexts(op, conn, texts)
+	}
+	_, err := a.addTexts(op, conn, texts)
 	return err
 }
 
-// AddPolicies adds policy rules to the storage.
-func (a *Adapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+// appendTexts pushes the encoded rules in a transaction.
+func (a *Adapter) appendTexts(op *operation, conn redis.Conn, texts [][]byte) error {
+	// The commands are flushed in batches, the transaction applies them at once.
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if _, err := a.sendBatches(conn, a.key, redis.Args{}.AddFlat(texts)); err != nil {
+		return err
+	}
+	if err := a.sendRevision(conn); err != nil {
+		return err
+	}
+	if _, err := conn.Do("EXEC"); err != nil {
+		return err
+	}
+	op.addWritten(len(texts), payloadSize(texts))
+	return nil
+}
+
+// addTexts pushes the encoded rules that are not stored yet. A single rule is
+// looked up with LPOS, while the script adding several rules copies the whole
+// list into a table, so it takes O(N) time and memory on the server.
+func (a *Adapter) addTexts(op *operation, conn redis.Conn, texts [][]byte) ([]bool, error) {
+	if len(texts) == 1 {
+		inserted, err := redis.Int(op.evalScript(conn, addPolicyScript, a.key, a.revisionArg(), texts[0]))
+		if err != nil {
+			return nil, err
+		}
+		op.addWritten(inserted, len(texts[0]))
+		return []bool{inserted == 1}, nil
+	}
+	reply, err := redis.Ints(a.evalBulkScript(op, conn, addPoliciesScript, redis.Args{}.AddFlat(texts)))
+	if err != nil {
+		return nil, err
+	}
+
+	added := make([]bool, len(reply))
+	inserted := 0
+	for i, v := range reply {
+		added[i] = v == 1
+		inserted += v
+	}
+	op.addWritten(inserted, payloadSize(texts))
+	return added, nil
+}
+
+// encodeRules encodes the rules as they are stored.
+func encodeRules(sec string, ptype string, rules [][]string) ([][]byte, error) {
 	var texts [][]byte
 	for _, rule := range rules {
-		line := savePolicyLine(ptype, rule)
+		line, err := savePolicyLine(sec, ptype, rule)
+		if err != nil {
+			return nil, err
+		}
 		text, err := json.Marshal(line)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		texts = append(texts, text)
 	}
-
-	conn := a.getConn()
-	defer a.release(conn)
-
-	_, err := conn.Do("RPUSH", redis.Args{}.Add(a.key).AddFlat(texts)...)
-	return err
+	return texts, nil
 }
 
 // RemovePolicies removes policy rules from the storage.
-func (a *Adapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+// The rules are removed in a transaction.
+func (a *Adapter) RemovePolicies(sec string, ptype string, rules [][]string) (err error) {
+	op := a.startOperation("RemovePolicies")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+
+	texts, err := encodeRules(sec, ptype, rules)
+	if err != nil || len(texts) == 0 {
+		return err
+	}
+	if a.writeBehind != nil {
+		return a.writeBehind.queue(a, sec, ptype, rules, texts, true)
+	}
+	a.beginWrite(op)
+
 	conn := a.getConn()
 	defer a.release(conn)
 
-	for _, rule := range rules {
-		line := savePolicyLine(ptype, rule)
-		text, err := json.Marshal(line)
-		if err != nil {
+	return a.removeTexts(op, conn, texts)
+}
+
+// removeTexts removes all occurrences of the encoded rules in a transaction.
+func (a *Adapter) removeTexts(op *operation, conn redis.Conn, texts [][]byte) error {
+	// The commands are flushed in batches, the transaction applies them at once.
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	for i, text := range texts {
+		if err := conn.Send("LREM", a.key, 0, text); err != nil {
 			return err
 		}
-		_, err = conn.Do("LREM", a.key, 1, text)
-		if err != nil {
-			return err
+		if (i+1)%a.batchSize == 0 {
+			if err := conn.Flush(); err != nil {
+				return err
+			}
 		}
 	}
+	if err := a.sendRevision(conn); err != nil {
+		return err
+	}
+	removed, err := redis.Ints(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+	for i, n := range removed[:len(texts)] {
+		op.addWritten(n, len(texts[i]))
+	}
 	return nil
 }
 
+// Deduplicate removes duplicated rules from the storage, keeping the first
+// occurrence of each, and returns the number of removed rules.
+func (a *Adapter) Deduplicate() (_ int, err error) {
+	op := a.startOperation("Deduplicate")
+	defer func() { op.end(err) }()
+	a.beginWrite(op)
+
+	conn := a.getConn()
+	defer a.release(conn)
+
+	removed, err := redis.Int(op.evalScript(conn, deduplicateScript, a.key, a.revisionArg()))
+	op.addWritten(removed, 0)
+	return removed, err
+}
+
 //FilteredAdapter
 
 // IsFiltered returns true if the loaded policy has been filtered.
 func (a *Adapter) IsFiltered() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.isFiltered
 }
 
@@ -466,6 +902,16 @@ type Filter struct {
 	V3    []string
 	V4    []string
 	V5    []string
+	// Where lists further conditions that the rules must all match.
+	Where []Condition `json:"-"`
+	// Or lists alternative filters. If it is not empty, the rules must also
+	// match at least one of them.
+	Or []*Filter `json:"-"`
+	// P and G constrain only the rules of the p and g sections, so that for
+	// instance all grouping rules but only the policy rules of one domain
+	// are loaded. A nil section filter does not constrain the section.
+	P *Filter `json:",omitempty"`
+	G *Filter `json:",omitempty"`
 }
 
 func filterToRegexPattern(filter *Filter) string {
@@ -482,7 +928,7 @@ func filterToRegexPattern(filter *Filter) string {
 		} else {
 			escapedV := make([]string, 0, len(v))
 			for _, s := range v {
-				escapedV = append(escapedV, regexp.QuoteMeta(s))
+				escapedV = append(escapedV, regexp.QuoteMeta(jsonString(s)))
 			}
 			args = append(args, "(?:"+strings.Join(escapedV, "|")+")") // (?:data2_admin|data1_admin)
 		}
@@ -496,6 +942,13 @@ func filterToRegexPattern(filter *Filter) string {
 	return pattern
 }
 
+// jsonString returns s as it appears in an encoded rule, which escapes for
+// instance quotes and &, < and >.
+func jsonString(s string) string {
+	text, _ := json.Marshal(s)
+	return string(text[1 : len(text)-1])
+}
+
 func escapeLuaPattern(s string) string {
 	var buf bytes.Buffer
 	for _, char := range s {
@@ -508,13 +961,16 @@ func escapeLuaPattern(s string) string {
 	return buf.String()
 }
 
-func filterFieldToLuaPattern(sec string, ptype string, fieldIndex int, fieldValues ...string) string {
-	args := []interface{}{ptype}
+func filterFieldToLuaPattern(sec string, ptype string, fieldIndex int, fieldValues ...string) (string, error) {
+	if err := checkSection(sec, ptype); err != nil {
+		return "", err
+	}
+	args := []interface{}{escapeLuaPattern(jsonString(ptype))}
 
 	idx := fieldIndex + len(fieldValues)
 	for i := 0; i < 6; i++ { // v0-v5
 		if fieldIndex <= i && idx > i && fieldValues[i-fieldIndex] != "" {
-			args = append(args, escapeLuaPattern(fieldValues[i-fieldIndex]))
+			args = append(args, escapeLuaPattern(jsonString(fieldValues[i-fieldIndex])))
 		} else {
 			args = append(args, ".*")
 		}
@@ -525,139 +981,151 @@ func filterFieldToLuaPattern(sec string, ptype string, fieldIndex int, fieldValu
 	pattern := fmt.Sprintf(
 		`^{"PType":"%s","V0":"%s","V1":"%s","V2":"%s","V3":"%s","V4":"%s","V5":"%s"}$`, args...,
 	)
-	return pattern
+	return pattern, nil
 }
 
-func (a *Adapter) loadFilteredPolicy(model model.Model, filter *Filter) error {
-	conn := a.getConn()
-	defer a.release(conn)
+func (a *Adapter) loadFilteredPolicy(op *operation, model model.Model, filter *Filter) error {
+	var values []interface{}
+	var m *ruleMatcher
+	var err error
+	serverFiltered := a.fcall && filter.simple()
+	if serverFiltered {
+		// The function filters the rules on the server.
+		text, err := json.Marshal(filter)
+		if err != nil {
+			return err
+		}
+		err = a.retry(func() error {
+			conn := a.getConn()
+			defer a.release(conn)
 
-	num, err := redis.Int(conn.Do("LLEN", a.key))
-	if err == redis.ErrNil {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-	values, err := redis.Values(conn.Do("LRANGE", a.key, 0, num))
-	if err != nil {
-		return err
+			values, err = redis.Values(op.evalScript(conn, loadFilteredPolicyScript, a.key, text))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		values, err = a.rangeRules()
+		if err != nil {
+			return err
+		}
+		m = newRuleMatcher(filter)
 	}
 
-	re := regexp.MustCompile(filterToRegexPattern(filter))
-
-	var line CasbinRule
-	for _, value := range values {
-		text, ok := value.([]byte)
-		if !ok {
-			// Amazon MemoryDB for Redis returns string instead of []byte
-			if textStr, ok := value.(string); ok {
-				text = []byte(textStr)
-			} else {
-				return errors.New("the type is wrong")
+	var skipped []*MalformedRuleError
+	for i, value := range values {
+		index := i
+		if serverFiltered {
+			// The position in the list is unknown.
+			index = -1
+		}
+		text, err := valueToBytes(value)
+		if err != nil {
+			if err = op.skipMalformedRule(&skipped, index, value, err); err != nil {
+				return err
 			}
+			continue
 		}
+		op.addRead(1, len(text))
 
-		if !re.Match(text) {
+		if m != nil && !m.matchText(text) {
 			continue
 		}
 
+		var line CasbinRule
 		err = json.Unmarshal(text, &line)
+		if err == nil {
+			if m != nil && !m.match(&line) {
+				continue
+			}
+			err = loadPolicyLine(line, model)
+		}
 		if err != nil {
-			return err
+			if err = op.skipMalformedRule(&skipped, index, value, err); err != nil {
+				return err
+			}
 		}
-		loadPolicyLine(line, model)
 	}
-	return nil
+	return skippedRulesError(skipped)
 }
 
 // LoadFilteredPolicy loads only policy rules that match the filter.
-func (a *Adapter) LoadFilteredPolicy(model model.Model, filter interface{}) error {
+// The filter replaces the loaded filters. Enforcer.LoadIncrementalFilteredPolicy
+// calls LoadFilteredPolicy as well, so to add a filter to the loaded ones,
+// call LoadIncrementalFilteredPolicy of the adapter with the model of the
+// enforcer instead.
+func (a *Adapter) LoadFilteredPolicy(model model.Model, filter interface{}) (err error) {
+	op := a.startOperation("LoadFilteredPolicy")
+	defer func() { op.end(err) }()
+
 	if filter == nil {
-		return a.LoadPolicy(model)
+		return a.loadPolicy(op, model)
 	}
+	return a.loadFilter(op, model, filter, false)
+}
 
-	var err error
-	switch f := filter.(type) {
-	case *Filter:
-		err = a.loadFilteredPolicy(model, f)
-	case Filter:
-		err = a.loadFilteredPolicy(model, &f)
-	default:
-		err = fmt.Errorf("invalid filter type")
-	}
+// RemoveFilteredPolicy removes policy rules that match the filter from the storage.
+func (a *Adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) (err error) {
+	op := a.startOperation("RemoveFilteredPolicy")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
 
+	pattern, err := filterFieldToLuaPattern(sec, ptype, fieldIndex, fieldValues...)
 	if err != nil {
 		return err
 	}
-	a.isFiltered = true
-	return nil
-}
-
-// RemoveFilteredPolicy removes policy rules that match the filter from the storage.
-func (a *Adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
-
-	pattern := filterFieldToLuaPattern(sec, ptype, fieldIndex, fieldValues...)
-
-	var getScript = redis.NewScript(1, `
-		local key = KEYS[1]
-		local pattern = ARGV[1]
-		
-		local r = redis.call('lrange', key, 0, -1)
-		for i=1, #r do 
-			if  string.find(r[i], pattern) then
-				redis.call('lset', key, i-1, '__CASBIN_DELETED__')
-			end
-		end
-		redis.call('lrem', key, 0, '__CASBIN_DELETED__')
-		return 
-	`)
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err := getScript.Do(conn, a.key, pattern)
+	_, err = op.evalScript(conn, removeFilteredPolicyScript, a.key, a.revisionArg(), pattern)
 	return err
 }
 
 // UpdatableAdapter
 
 // UpdatePolicy updates a new policy rule to DB.
-func (a *Adapter) UpdatePolicy(sec string, ptype string, oldRule, newPolicy []string) error {
-	oldLine := savePolicyLine(ptype, oldRule)
+func (a *Adapter) UpdatePolicy(sec string, ptype string, oldRule, newPolicy []string) (err error) {
+	op := a.startOperation("UpdatePolicy")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
+
+	oldLine, err := savePolicyLine(sec, ptype, oldRule)
+	if err != nil {
+		return err
+	}
 	textOld, err := json.Marshal(oldLine)
 	if err != nil {
 		return err
 	}
-	newLine := savePolicyLine(ptype, newPolicy)
+	newLine, err := savePolicyLine(sec, ptype, newPolicy)
+	if err != nil {
+		return err
+	}
 	textNew, err := json.Marshal(newLine)
 	if err != nil {
 		return err
 	}
 
-	var getScript = redis.NewScript(1, `
-		local key = KEYS[1]
-		local old = ARGV[1]
-		local newRule = ARGV[2]
-	
-		local r = redis.call('lrange', key, 0, -1)
-		for i=1,#r do
-			if r[i] == old then
-				redis.call('lset', key, i-1, newRule)
-				return true
-			end
-		end
-		return false
-	`)
-
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err = getScript.Do(conn, a.key, textOld, textNew)
+	updated, err := redis.Bool(op.evalScript(conn, updatePolicyScript, a.key, a.revisionArg(), textOld, textNew))
+	if updated {
+		op.addWritten(1, len(textNew))
+	}
 	return err
 }
 
-func (a *Adapter) UpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+// UpdatePolicies updates some policy rules to DB.
+func (a *Adapter) UpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) (err error) {
+	op := a.startOperation("UpdatePolicies")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
 
 	if len(oldRules) != len(newRules) {
 		return errors.New("oldRules and newRules should have the same length")
@@ -666,94 +1134,75 @@ func (a *Adapter) UpdatePolicies(sec string, ptype string, oldRules, newRules []
 	oldPolicies := make([]string, 0, len(oldRules))
 	newPolicies := make([]string, 0, len(newRules))
 	for _, oldRule := range oldRules {
-		textOld, err := json.Marshal(savePolicyLine(ptype, oldRule))
+		oldLine, err := savePolicyLine(sec, ptype, oldRule)
+		if err != nil {
+			return err
+		}
+		textOld, err := json.Marshal(oldLine)
 		if err != nil {
 			return err
 		}
 		oldPolicies = append(oldPolicies, string(textOld))
 	}
 	for _, newRule := range newRules {
-		textNew, err := json.Marshal(savePolicyLine(ptype, newRule))
+		newLine, err := savePolicyLine(sec, ptype, newRule)
+		if err != nil {
+			return err
+		}
+		textNew, err := json.Marshal(newLine)
 		if err != nil {
 			return err
 		}
 		newPolicies = append(newPolicies, string(textNew))
 	}
 
-	// Initialize a package-level variable with a script.
-	var getScript = redis.NewScript(1, `
-		local key = KEYS[1]
-		local len = #ARGV/2
-		
-		local map = {}
-		for i = 1, len, 1 do
-			map[ARGV[i]] = ARGV[i + len] -- map[oldRule] = newRule
-		end
-		
-		local r = redis.call('lrange', key, 0, -1)
-		for i=1,#r do
-			if map[r[i]] ~= nil then
-				redis.call('lset', key, i-1, map[r[i]])
-				-- return true
-			end
-		end
-		
-		return false
-	`)
-	args := redis.Args{}.Add(a.key).AddFlat(oldPolicies).AddFlat(newPolicies)
+	args := redis.Args{}.Add(a.key, a.revisionArg()).AddFlat(oldPolicies).AddFlat(newPolicies)
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	_, err := getScript.Do(conn, args...)
+	updated, err := redis.Int(op.evalScript(conn, updatePoliciesScript, args...))
+	op.addWritten(updated, 0)
 	return err
 }
 
-func (a *Adapter) UpdateFilteredPolicies(sec string, ptype string, newPolicies [][]string, fieldIndex int, fieldValues ...string) ([][]string, error) {
-	// UpdateFilteredPolicies deletes old rules and adds new rules.
+// UpdateFilteredPolicies deletes the rules that match the filter and adds the
+// new rules in one atomic step, and returns the deleted rules.
+// New rules that remain stored after the deletion are not added twice.
+func (a *Adapter) UpdateFilteredPolicies(sec string, ptype string, newPolicies [][]string, fieldIndex int, fieldValues ...string) (_ [][]string, err error) {
+	op := a.startOperation("UpdateFilteredPolicies")
+	defer func() { op.end(err) }()
+	op.setPType(ptype)
+	a.beginWrite(op)
 
 	oldP := make([]string, 0)
 	newP := make([]string, 0, len(newPolicies))
 	for _, newRule := range newPolicies {
-		textNew, err := json.Marshal(savePolicyLine(ptype, newRule))
+		newLine, err := savePolicyLine(sec, ptype, newRule)
+		if err != nil {
+			return nil, err
+		}
+		textNew, err := json.Marshal(newLine)
 		if err != nil {
 			return nil, err
 		}
 		newP = append(newP, string(textNew))
 	}
 
-	pattern := filterFieldToLuaPattern(sec, ptype, fieldIndex, fieldValues...)
-
-	// Initialize a package-level variable with a script.
-	var getScript = redis.NewScript(1, `
-		local key = KEYS[1]
-		local pattern = ARGV[1]
-		
-		local ret = {}
-		local r = redis.call('lrange', key, 0, -1)
-		for i=1, #r do 
-			if  string.find(r[i], pattern) then
-        		table.insert(ret, r[i])
-				redis.call('lset', key, i-1, '__CASBIN_DELETED__')
-			end
-		end
-		redis.call('lrem', key, 0, '__CASBIN_DELETED__')
-		
-		local r = redis.call('lrange', key, 0, -1)
-		for i=2,#r do
-			redis.call('rpush', key, ARGV[i])
-		end
-		
-		return ret
-	`)
-	args := redis.Args{}.Add(a.key).Add(pattern).AddFlat(newP)
-	//r, err := getScript.Do(a.conn, args...)
-	//reply, err := redis.Values(r, err)
+	pattern, err := filterFieldToLuaPattern(sec, ptype, fieldIndex, fieldValues...)
+	if err != nil {
+		return nil, err
+	}
+
+	args := redis.Args{}.Add(a.key, a.revisionArg()).Add(pattern).AddFlat(newP)
+	for _, text := range newP {
+		op.addWritten(1, len(text))
+	}
 
 	conn := a.getConn()
 	defer a.release(conn)
 
-	reply, err := redis.Values(getScript.Do(conn, args...))
+	reply, err := redis.Values(op.evalScript(conn, updateFilteredPoliciesScript, args...))
 	if err != nil {
 		return nil, err
 	}
@@ -764,12 +1213,13 @@ func (a *Adapter) UpdateFilteredPolicies(sec string, ptype string, newPolicies [
 
 	ret := make([][]string, 0, len(oldP))
 	for _, oldRule := range oldP {
+		op.addRead(1, len(oldRule))
 		var line CasbinRule
 		if err := json.Unmarshal([]byte(oldRule), &line); err != nil {
 			return nil, err
 		}
 
-		ret = append(ret, line.toStringPolicy())
+		ret = append(ret, line.toStringPolicy()[1:])
 	}
 
 	return ret, nil
@@ -15,9 +15,12 @@
 package redisadapter
 
 import (
+	"encoding/json"
 	"log"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/util"
@@ -257,6 +260,161 @@ func testAddPolicies(t *testing.T, a *Adapter) {
 	testGetPolicy(t, e, [][]string{{"max", "data2", "read"}, {"max", "data1", "write"}})
 }
 
+func testAddPolicyIdempotent(t *testing.T, a *Adapter) {
+	// Initialize some policy in DB.
+	initPolicy(t, a)
+
+	var err error
+	logErr := func(action string) {
+		if err != nil {
+			t.Fatalf("test action[%s] failed, err: %v", action, err)
+		}
+	}
+
+	added, err := a.AddPolicyEx("p", "p", []string{"alice", "data1", "read"})
+	logErr("AddPolicyEx")
+	if added {
+		t.Error("AddPolicyEx should not insert an existing rule")
+	}
+
+	added, err = a.AddPolicyEx("p", "p", []string{"max", "data1", "read"})
+	logErr("AddPolicyEx2")
+	if !added {
+		t.Error("AddPolicyEx should insert a new rule")
+	}
+
+	// Retrying an add must not create a duplicate.
+	err = a.AddPolicy("p", "p", []string{"max", "data1", "read"})
+	logErr("AddPolicy")
+
+	addedRules, err := a.AddPoliciesEx("p", "p", [][]string{{"max", "data1", "read"}, {"max", "data2", "read"}, {"max", "data2", "read"}})
+	logErr("AddPoliciesEx")
+	if len(addedRules) != 3 || addedRules[0] || !addedRules[1] || addedRules[2] {
+		t.Errorf("AddPoliciesEx: %v, supposed to be [false true false]", addedRules)
+	}
+
+	testStoredCount(t, a, 7)
+}
+
+func TestUncheckedAdds(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_unchecked"), WithUncheckedAdds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	initPolicy(t, a)
+
+	logErr := func(action string) {
+		if err != nil {
+			t.Fatalf("test action[%s] failed, err: %v", action, err)
+		}
+	}
+
+	// Adds are not checked, so a stored rule is duplicated.
+	err = a.AddPolicy("p", "p", []string{"alice", "data1", "read"})
+	logErr("AddPolicy")
+	err = a.AddPolicies("p", "p", [][]string{{"max", "data1", "read"}, {"max", "data2", "read"}})
+	logErr("AddPolicies")
+	testStoredCount(t, a, 8)
+
+	added, err := a.AddPolicyEx("p", "p", []string{"max", "data1", "read"})
+	logErr("AddPolicyEx")
+	if added {
+		t.Error("AddPolicyEx should not insert an existing rule")
+	}
+
+	removed, err := a.Deduplicate()
+	logErr("Deduplicate")
+	if removed != 1 {
+		t.Errorf("Deduplicate removed %d rules, supposed to be 1", removed)
+	}
+	testStoredCount(t, a, 7)
+}
+
+func testDeduplicate(t *testing.T, a *Adapter) {
+	// Initialize some policy in DB.
+	initPolicy(t, a)
+
+	conn := a.getConn()
+	line, err := savePolicyLine("p", "p", []string{"alice", "data1", "read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := json.Marshal(line)
+	if err == nil {
+		_, err = conn.Do("RPUSH", a.key, text, text)
+	}
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 7)
+
+	removed, err := a.Deduplicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("Deduplicate removed %d rules, supposed to be 2", removed)
+	}
+	testStoredCount(t, a, 5)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+}
+
+func testSection(t *testing.T, a *Adapter) {
+	// Initialize some policy in DB.
+	initPolicy(t, a)
+
+	if err := a.AddPolicy("x", "p", []string{"alice", "data1", "write"}); err == nil {
+		t.Error("AddPolicy should fail on an unknown section")
+	}
+	if err := a.AddPolicies("g", "p", [][]string{{"alice", "data1", "write"}}); err == nil {
+		t.Error("AddPolicies should fail on a ptype of another section")
+	}
+	if err := a.RemovePolicy("r", "r", []string{"alice", "data1", "read"}); err == nil {
+		t.Error("RemovePolicy should fail on a request section")
+	}
+	if err := a.RemoveFilteredPolicy("p", "g", 0, "alice"); err == nil {
+		t.Error("RemoveFilteredPolicy should fail on a ptype of another section")
+	}
+	if err := a.UpdatePolicy("e", "p", []string{"alice", "data1", "read"}, []string{"alice", "data1", "write"}); err == nil {
+		t.Error("UpdatePolicy should fail on an unknown section")
+	}
+	if err := a.UpdatePolicies("g", "p", [][]string{{"alice", "data1", "read"}}, [][]string{{"alice", "data1", "write"}}); err == nil {
+		t.Error("UpdatePolicies should fail on a ptype of another section")
+	}
+	if _, err := a.UpdateFilteredPolicies("m", "p", [][]string{{"alice", "data1", "write"}}, 0, "alice"); err == nil {
+		t.Error("UpdateFilteredPolicies should fail on an unknown section")
+	}
+	testStoredCount(t, a, 5)
+
+	// Only the grouping rule of alice is removed.
+	if err := a.RemoveFilteredPolicy("g", "g", 0, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+	if len(e.GetGroupingPolicy()) != 0 {
+		t.Error("Grouping policy: ", e.GetGroupingPolicy(), ", supposed to be empty")
+	}
+}
+
+func testStoredCount(t *testing.T, a *Adapter, expected int) {
+	t.Helper()
+	conn := a.getConn()
+	defer a.release(conn)
+
+	num, err := redis.Int(conn.Do("LLEN", a.key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != expected {
+		t.Errorf("Stored rules: %d, supposed to be %d", num, expected)
+	}
+}
+
 func testUpdatePolicies(t *testing.T, a *Adapter) {
 	// Initialize some policy in DB.
 	initPolicy(t, a)
@@ -309,6 +467,93 @@ func testUpdateFilteredPolicies(t *testing.T, a *Adapter) {
 	testGetPolicyWithoutOrder(t, e, [][]string{{"alice", "data1", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"bob", "data2", "read"}})
 }
 
+func testUpdateFilteredPoliciesCases(t *testing.T, a *Adapter) {
+	var err error
+	logErr := func(action string) {
+		if err != nil {
+			t.Fatalf("test action[%s] failed, err: %v", action, err)
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		newPolicies [][]string
+		fieldIndex  int
+		fieldValues []string
+		oldRules    [][]string
+		res         [][]string
+	}{
+		{
+			name:        "no matching rules",
+			newPolicies: [][]string{{"max", "data3", "read"}},
+			fieldValues: []string{"max"},
+			oldRules:    [][]string{},
+			res:         [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"max", "data3", "read"}},
+		},
+		{
+			name:        "no new rules",
+			newPolicies: [][]string{},
+			fieldValues: []string{"data2_admin"},
+			oldRules:    [][]string{{"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}},
+			res:         [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}},
+		},
+		{
+			name:        "more new rules than old rules",
+			newPolicies: [][]string{{"alice", "data1", "write"}, {"alice", "data2", "read"}, {"alice", "data3", "read"}},
+			fieldValues: []string{"alice"},
+			oldRules:    [][]string{{"alice", "data1", "read"}},
+			res:         [][]string{{"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"alice", "data1", "write"}, {"alice", "data2", "read"}, {"alice", "data3", "read"}},
+		},
+		{
+			name:        "more old rules than new rules",
+			newPolicies: [][]string{{"data3_admin", "data3", "read"}},
+			fieldIndex:  1,
+			fieldValues: []string{"data2"},
+			oldRules:    [][]string{{"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}},
+			res:         [][]string{{"alice", "data1", "read"}, {"data3_admin", "data3", "read"}},
+		},
+		{
+			name:        "new rule already stored",
+			newPolicies: [][]string{{"bob", "data2", "write"}, {"bob", "data2", "read"}},
+			fieldIndex:  2,
+			fieldValues: []string{"read"},
+			oldRules:    [][]string{{"alice", "data1", "read"}, {"data2_admin", "data2", "read"}},
+			res:         [][]string{{"bob", "data2", "write"}, {"data2_admin", "data2", "write"}, {"bob", "data2", "read"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		initPolicy(t, a)
+
+		var oldRules [][]string
+		oldRules, err = a.UpdateFilteredPolicies("p", "p", tc.newPolicies, tc.fieldIndex, tc.fieldValues...)
+		logErr(tc.name)
+		if !arrayEqualsWithoutOrder(oldRules, tc.oldRules) {
+			t.Errorf("%s: old rules: %v, supposed to be %v", tc.name, oldRules, tc.oldRules)
+		}
+
+		e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+		testGetPolicyWithoutOrder(t, e, tc.res)
+		testStoredCount(t, a, len(tc.res)+1)
+	}
+
+	// The empty list is left empty when there is nothing to add.
+	a.dropTable()
+	oldRules, err := a.UpdateFilteredPolicies("p", "p", [][]string{}, 0, "alice")
+	logErr("UpdateFilteredPolicies")
+	if len(oldRules) != 0 {
+		t.Errorf("old rules: %v, supposed to be empty", oldRules)
+	}
+	testStoredCount(t, a, 0)
+
+	oldRules, err = a.UpdateFilteredPolicies("p", "p", [][]string{{"alice", "data1", "read"}}, 0, "alice")
+	logErr("UpdateFilteredPolicies2")
+	if len(oldRules) != 0 {
+		t.Errorf("old rules: %v, supposed to be empty", oldRules)
+	}
+	testStoredCount(t, a, 1)
+}
+
 func testGetPolicyWithoutOrder(t *testing.T, e *casbin.Enforcer, res [][]string) {
 	myRes := e.GetPolicy()
 	log.Print("Policy: ", myRes)
@@ -366,8 +611,12 @@ func TestAdapters(t *testing.T) {
 	testFilteredPolicy(t, a)
 	testAddPolicies(t, a)
 	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testSection(t, a)
 	testUpdatePolicies(t, a)
 	testUpdateFilteredPolicies(t, a)
+	testUpdateFilteredPoliciesCases(t, a)
 }
 
 func TestAdapterWithOption(t *testing.T) {
@@ -381,8 +630,12 @@ func TestAdapterWithOption(t *testing.T) {
 	testFilteredPolicy(t, a)
 	testAddPolicies(t, a)
 	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testSection(t, a)
 	testUpdatePolicies(t, a)
 	testUpdateFilteredPolicies(t, a)
+	testUpdateFilteredPoliciesCases(t, a)
 }
 
 func TestPoolAdapters(t *testing.T) {
@@ -400,8 +653,12 @@ func TestPoolAdapters(t *testing.T) {
 	testFilteredPolicy(t, a)
 	testAddPolicies(t, a)
 	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testSection(t, a)
 	testUpdatePolicies(t, a)
 	testUpdateFilteredPolicies(t, a)
+	testUpdateFilteredPoliciesCases(t, a)
 }
 
 func TestPoolAndOptionsAdapters(t *testing.T) {
@@ -419,6 +676,98 @@ func TestPoolAndOptionsAdapters(t *testing.T) {
 	testFilteredPolicy(t, a)
 	testAddPolicies(t, a)
 	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testSection(t, a)
 	testUpdatePolicies(t, a)
 	testUpdateFilteredPolicies(t, a)
+	testUpdateFilteredPoliciesCases(t, a)
+}
+
+func TestClose(t *testing.T) {
+	a, err := NewAdapter("tcp", "127.0.0.1:6379")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", "examples/rbac_policy.csv")
+
+	if err = a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Close(); err != nil {
+		t.Errorf("Close should be idempotent, err: %v", err)
+	}
+	if err = a.LoadPolicy(e.GetModel()); err != ErrAdapterClosed {
+		t.Errorf("LoadPolicy: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+	if err = a.SavePolicy(e.GetModel()); err != ErrAdapterClosed {
+		t.Errorf("SavePolicy: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+	if err = a.AddPolicy("p", "p", []string{"alice", "data1", "read"}); err != ErrAdapterClosed {
+		t.Errorf("AddPolicy: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+	if err = a.RemoveFilteredPolicy("p", "p", 0, "alice"); err != ErrAdapterClosed {
+		t.Errorf("RemoveFilteredPolicy: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+	if a._pool.ActiveCount() != 0 {
+		t.Errorf("The pool created by the adapter should be closed")
+	}
+
+	// A pool given by the caller is not closed.
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:6379")
+		},
+	}
+	defer pool.Close()
+	a, err = NewAdapterWithPool(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err = conn.Do("PING"); err != nil {
+		t.Errorf("The pool of the caller should not be closed, err: %v", err)
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_options"),
+		WithConnectTimeout(time.Second), WithReadTimeout(time.Second), WithWriteTimeout(time.Second), WithKeepAlive(time.Minute),
+		WithMaxIdle(2), WithMaxActive(1), WithIdleTimeout(time.Minute), WithMaxConnLifetime(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if a._pool.MaxIdle != 2 || a._pool.MaxActive != 1 || a._pool.IdleTimeout != time.Minute || a._pool.MaxConnLifetime != time.Hour || !a._pool.Wait {
+		t.Errorf("The pool is not configured by the options")
+	}
+	testSaveLoad(t, a)
+
+	// With a single connection the operations wait for each other.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+			if err := a.LoadPolicy(e.GetModel()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	start := time.Now()
+	_, err = NewAdapterWithOption(WithNetwork("tcp"), WithAddress("10.255.255.1:6379"), WithConnectTimeout(100*time.Millisecond), WithRetry(0, 0, 0))
+	if err == nil {
+		t.Error("Connecting to an unreachable address should fail")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("The connect timeout is not applied")
+	}
 }
@@ -0,0 +1,120 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// defaultBatchSize is the default maximum number of arguments sent with a
+// single command.
+const defaultBatchSize = 1000
+
+// stagingTTL bounds the lifetime of a staging key left behind by a client
+// that failed before applying it.
+const stagingTTL = time.Hour
+
+// WithBatchSize sets the maximum number of rules or arguments sent with a
+// single command, 1000 by default. Larger writes are split into batches that
+// are pipelined on one connection and applied atomically, either in a
+// transaction or from a staging key.
+func WithBatchSize(size int) Option {
+	return func(a *Adapter) {
+		if size > 0 {
+			a.batchSize = size
+		}
+	}
+}
+
+// randomToken returns a random hex string.
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// evalBulkScript invokes a script whose arguments are read from the list at
+// KEYS[2] when ARGV is empty, passing the revision counter as KEYS[3]. If
+// there are more arguments than the batch size, they are pushed to a staging
+// key in pipelined batches first.
+func (a *Adapter) evalBulkScript(op *operation, conn redis.Conn, s *luaScript, args redis.Args) (interface{}, error) {
+	if len(args) <= a.batchSize {
+		return op.evalScript(conn, s, redis.Args{}.Add(a.key, a.key+":staging", a.revisionArg()).Add(args...)...)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	staging := a.key + ":staging:" + token
+	if err = a.pushBatches(conn, staging, args); err != nil {
+		_, _ = conn.Do("DEL", staging)
+		return nil, err
+	}
+	reply, err := op.evalScript(conn, s, a.key, staging, a.revisionArg())
+	if err != nil {
+		_, _ = conn.Do("DEL", staging)
+	}
+	return reply, err
+}
+
+// pushBatches appends args to the list at key in pipelined batches, and
+// makes the key expire after stagingTTL.
+func (a *Adapter) pushBatches(conn redis.Conn, key string, args redis.Args) error {
+	pending, err := a.sendBatches(conn, key, args)
+	if err == nil {
+		if err = conn.Send("PEXPIRE", key, stagingTTL.Milliseconds()); err == nil {
+			pending++
+		}
+	}
+	if err == nil {
+		err = conn.Flush()
+	}
+
+	// Drain the pipelined replies even on failure to keep the connection usable.
+	for ; pending > 0; pending-- {
+		if _, rerr := conn.Receive(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// sendBatches sends the RPUSH commands appending args to the list at key in
+// batches, flushing each batch, and returns the number of commands sent.
+// The caller receives their replies.
+func (a *Adapter) sendBatches(conn redis.Conn, key string, args redis.Args) (int, error) {
+	sent := 0
+	for start := 0; start < len(args); start += a.batchSize {
+		end := start + a.batchSize
+		if end > len(args) {
+			end = len(args)
+		}
+		if err := conn.Send("RPUSH", redis.Args{}.Add(key).Add(args[start:end]...)...); err != nil {
+			return sent, err
+		}
+		sent++
+		if err := conn.Flush(); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
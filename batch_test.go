@@ -0,0 +1,105 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func testNoStagingKeys(t *testing.T, a *Adapter) {
+	t.Helper()
+	conn := a.getConn()
+	defer a.release(conn)
+
+	keys, err := redis.Strings(conn.Do("KEYS", a.key+":staging*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Staging keys left: %v", keys)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_batch"),
+		WithBatchSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	initPolicy(t, a)
+
+	var rules [][]string
+	for i := 0; i < 25; i++ {
+		rules = append(rules, []string{fmt.Sprintf("user%d", i), "data1", "read"})
+	}
+	// The first rule is already stored.
+	rules = append([][]string{{"alice", "data1", "read"}}, rules...)
+	added, err := a.AddPoliciesEx("p", "p", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 26 || added[0] || !added[1] || !added[25] {
+		t.Errorf("AddPoliciesEx: %v, supposed to skip only the first rule", added)
+	}
+	testStoredCount(t, a, 30)
+	testNoStagingKeys(t, a)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	if len(e.GetPolicy()) != 29 {
+		t.Errorf("Policy: %d rules, supposed to be 29", len(e.GetPolicy()))
+	}
+
+	if err = a.RemovePolicies("p", "p", rules[1:]); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 5)
+
+	// The model still holds the removed rules, more than the batch size.
+	diff, err := a.SavePolicyDiff(e.GetModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Added != 25 || diff.Removed != 0 || diff.Unchanged != 5 {
+		t.Errorf("SavePolicyDiff: %+v", *diff)
+	}
+	testStoredCount(t, a, 30)
+	testNoStagingKeys(t, a)
+
+	var csv strings.Builder
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&csv, "p, user%d, data2, write\n", i)
+	}
+	if err = a.ImportCSV(strings.NewReader(csv.String()), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 25)
+
+	// Both the removed and the added rules are more than the batch size.
+	if err = a.SaveFilteredPolicy(e.GetModel(), &Filter{PType: []string{"p"}}); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 29)
+	if err = a.ImportCSV(strings.NewReader(csv.String()), ImportModeMerge); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 54)
+	testNoStagingKeys(t, a)
+}
@@ -0,0 +1,384 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// CacheInvalidation selects how an adapter created with WithCache learns
+// that its copy of the rules is outdated.
+type CacheInvalidation int
+
+const (
+	// CacheRevision compares the revision counter of the rules before each
+	// load, which takes a single GET instead of reading the whole list.
+	CacheRevision CacheInvalidation = iota
+	// CacheKeyspaceNotifications subscribes to the keyspace notifications of
+	// the key and serves loads without any round trip while subscribed. The
+	// server must be configured with notify-keyspace-events including K and
+	// the l and g classes, e.g. "Klg". Otherwise, and while the subscription
+	// is being reestablished, the revision counter is compared instead.
+	CacheKeyspaceNotifications
+)
+
+// errNotificationsDisabled stops the subscriber of a server that does not
+// send the keyspace notifications of the key.
+var errNotificationsDisabled = errors.New("keyspace notifications are disabled")
+
+// WithCache makes LoadPolicy keep a decoded copy of the stored rules and
+// serve the following loads from it as long as the rules did not change. It
+// implies WithRevisionCounter.
+//
+// Every adapter writing the same key must be created with WithCache or
+// WithRevisionCounter, and rules written by other means must increment the
+// revision counter as well, unless the keyspace notifications are enabled.
+// Rule sets with malformed rules are not cached.
+func WithCache(invalidation CacheInvalidation) Option {
+	return func(a *Adapter) {
+		a.cache = &policyCache{invalidation: invalidation}
+		a.revision = true
+	}
+}
+
+// WithRevisionCounter makes every write that changes the stored rules
+// increment a counter stored under the key suffixed with ":revision", in the
+// same script or transaction as the write. Adapters created with WithCache
+// compare it to find out whether their copy of the rules is outdated.
+func WithRevisionCounter() Option {
+	return func(a *Adapter) {
+		a.revision = true
+	}
+}
+
+// policyCache is the copy of the rules of an adapter created with WithCache.
+type policyCache struct {
+	invalidation CacheInvalidation
+
+	mu         sync.Mutex
+	rules      []cachedRule
+	cached     bool
+	revision   int64
+	subscribed bool
+	// generation is incremented whenever the cached rules are dropped, so
+	// that a load that started before does not cache outdated rules.
+	generation uint64
+
+	// psc is the connection of the subscriber, unsubscribed to stop it.
+	psc      *redis.PubSubConn
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (a *Adapter) revisionKey() string {
+	return a.key + ":revision"
+}
+
+// revisionArg returns the revision counter passed to the write scripts, or ""
+// if the adapter does not maintain it.
+func (a *Adapter) revisionArg() string {
+	if !a.revision {
+		return ""
+	}
+	return a.revisionKey()
+}
+
+// sendRevision queues the increment of the revision counter in a
+// transaction, if the adapter maintains it.
+func (a *Adapter) sendRevision(conn redis.Conn) error {
+	if !a.revision {
+		return nil
+	}
+	return conn.Send("INCR", a.revisionKey())
+}
+
+// start starts the subscriber of the keyspace notifications.
+func (c *policyCache) start(a *Adapter) {
+	if c.invalidation != CacheKeyspaceNotifications {
+		return
+	}
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.subscribe(a)
+}
+
+// close stops the subscriber and waits for it to return.
+func (c *policyCache) close() {
+	if c.stop == nil {
+		return
+	}
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		c.mu.Lock()
+		if c.psc != nil {
+			_ = c.psc.PUnsubscribe()
+		}
+		c.mu.Unlock()
+		<-c.done
+	})
+}
+
+// invalidate drops the cached rules.
+func (c *policyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = nil
+	c.cached = false
+	c.generation++
+}
+
+// subscribe receives the keyspace notifications until the cache is closed,
+// subscribing again with a growing backoff whenever the connection fails.
+func (c *policyCache) subscribe(a *Adapter) {
+	defer close(c.done)
+	for attempt := 0; ; attempt++ {
+		err := c.receive(a)
+		if errors.Is(err, errNotificationsDisabled) {
+			if a.logger != nil {
+				a.logger.LogAttrs(context.Background(), slog.LevelWarn, "redis adapter cache falls back to the revision counter",
+					slog.String("key", a.key),
+					slog.Any("error", err))
+			}
+			return
+		}
+		c.mu.Lock()
+		if c.subscribed {
+			attempt = 0
+		}
+		c.subscribed = false
+		c.mu.Unlock()
+		c.invalidate()
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(a.retryBackoff(attempt)):
+		}
+	}
+}
+
+// receive subscribes to the notifications of the key in every database and
+// drops the cached rules on each of them.
+func (c *policyCache) receive(a *Adapter) error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return ErrAdapterClosed
+	}
+	conn := a._pool.Get()
+	a.mu.RUnlock()
+	defer func() {
+		// close may be unsubscribing on the connection.
+		c.mu.Lock()
+		c.psc = nil
+		c.mu.Unlock()
+		conn.Close()
+	}()
+
+	if err := checkKeyspaceNotifications(conn); err != nil {
+		return err
+	}
+
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.PSubscribe("__keyspace@*__:" + escapeGlob(a.key)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	select {
+	case <-c.stop:
+		c.mu.Unlock()
+		return nil
+	default:
+		c.psc = &psc
+	}
+	c.mu.Unlock()
+
+	for {
+		switch v := psc.ReceiveWithTimeout(0).(type) {
+		case redis.Subscription:
+			if v.Count == 0 {
+				return nil
+			}
+			// Writes before the subscription were not notified.
+			c.mu.Lock()
+			c.subscribed = true
+			c.mu.Unlock()
+			c.invalidate()
+		case redis.Message:
+			c.invalidate()
+		case error:
+			return v
+		}
+	}
+}
+
+// checkKeyspaceNotifications returns errNotificationsDisabled unless the
+// server sends the keyspace notifications of the list and generic commands.
+// The tests replace it for servers without the CONFIG command.
+var checkKeyspaceNotifications = func(conn redis.Conn) error {
+	reply, err := redis.Strings(conn.Do("CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil {
+		return errors.Join(errNotificationsDisabled, err)
+	}
+	if len(reply) != 2 || !notifiesKeyspace(reply[1]) {
+		return errNotificationsDisabled
+	}
+	return nil
+}
+
+// notifiesKeyspace reports whether the notify-keyspace-events flags make the
+// server send the keyspace notifications of the list and generic commands.
+func notifiesKeyspace(flags string) bool {
+	return strings.Contains(flags, "K") &&
+		(strings.Contains(flags, "A") || strings.Contains(flags, "l") && strings.Contains(flags, "g"))
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cachedRule is a stored rule with its position and value, which are kept to
+// report the rule if it cannot be loaded into a model.
+type cachedRule struct {
+	index int
+	value interface{}
+	line  CasbinRule
+	// err is the error decoding the rule.
+	err error
+}
+
+// load returns the stored rules, from the cache if they did not change. The
+// returned rules must not be modified. Malformed rules are returned with their
+// error, and rule sets that have any are not cached.
+func (c *policyCache) load(op *operation, a *Adapter) ([]cachedRule, error) {
+	c.mu.Lock()
+	if c.cached && c.subscribed {
+		rules := c.rules
+		c.mu.Unlock()
+		op.setCacheHit(true)
+		return rules, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	var rules []cachedRule
+	var revision int64
+	var values []interface{}
+	hit := false
+	err := a.retry(func() error {
+		conn := a.getConn()
+		defer a.release(conn)
+
+		current, err := redis.Int64(conn.Do("GET", a.revisionKey()))
+		if err != nil && err != redis.ErrNil {
+			return err
+		}
+		c.mu.Lock()
+		if c.cached && c.revision == current {
+			rules, hit = c.rules, true
+		}
+		c.mu.Unlock()
+		if hit {
+			return nil
+		}
+
+		// The revision is incremented along with each write, so the rules
+		// match the revision read with them.
+		if err = conn.Send("MULTI"); err != nil {
+			return err
+		}
+		if err = conn.Send("GET", a.revisionKey()); err != nil {
+			return err
+		}
+		if err = conn.Send("LRANGE", a.key, 0, -1); err != nil {
+			return err
+		}
+		reply, err := redis.Values(conn.Do("EXEC"))
+		if err != nil {
+			return err
+		}
+		revision, err = redis.Int64(reply[0], nil)
+		if err != nil && err != redis.ErrNil {
+			return err
+		}
+		values, err = redis.Values(reply[1], nil)
+		return err
+	})
+	op.setCacheHit(hit)
+	if err != nil || hit {
+		return rules, err
+	}
+
+	rules = make([]cachedRule, len(values))
+	malformed := false
+	for i, value := range values {
+		rule := &rules[i]
+		rule.index, rule.value = i, value
+		text, err := valueToBytes(value)
+		if err == nil {
+			op.addRead(1, len(text))
+			err = json.Unmarshal(text, &rule.line)
+		}
+		if err != nil {
+			rule.err = err
+			malformed = true
+		}
+	}
+	if malformed {
+		// Keep reporting the malformed rules on each load.
+		return rules, nil
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.rules = rules
+		c.cached = true
+		c.revision = revision
+	}
+	c.mu.Unlock()
+	return rules, nil
+}
+
+// beginWrite marks op as a write of the stored rules and writes the changes
+// queued in write-behind mode first, so that the changes are applied in order.
+func (a *Adapter) beginWrite(op *operation) {
+	op.write = true
+	a.flushWrites()
+}
+
+// endWrite drops the cached rules after a write, whether it failed or not,
+// since it may have been applied. The revision counter is incremented by the
+// write itself.
+func (a *Adapter) endWrite(op *operation) {
+	if a.cache != nil {
+		a.cache.invalidate()
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func testCacheInvalidation(t *testing.T, invalidation CacheInvalidation) {
+	m := &recordedMetrics{}
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_cache"),
+		WithCache(invalidation), WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_cache"), WithRevisionCounter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	testLoad := func(name string, cached bool) *casbin.Enforcer {
+		t.Helper()
+		m.take()
+		enforcer, err := casbin.NewEnforcer("examples/rbac_model.conf", a)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		stats := m.take()
+		if len(stats) != 1 || (stats[0].RulesRead == 0) != cached {
+			t.Errorf("%s: %+v, supposed to be served from the cache: %t", name, stats, cached)
+		}
+		return enforcer
+	}
+
+	initPolicy(t, b)
+	testLoad("first load", false)
+	testLoad("second load", true)
+
+	// Writes of other adapters are seen.
+	if err = b.AddPolicy("p", "p", []string{"carol", "data3", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	enforcer := testLoad("load after AddPolicy", false)
+	testGetPolicy(t, enforcer, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"carol", "data3", "read"}})
+
+	// So are the own writes.
+	if _, err = enforcer.RemovePolicy("carol", "data3", "read"); err != nil {
+		t.Fatal(err)
+	}
+	testLoad("load after RemovePolicy", false)
+	testLoad("load after load", true)
+
+	// Writes bypassing the adapter must increment the revision.
+	text, _ := json.Marshal(CasbinRule{PType: "p", V0: "dave", V1: "data4", V2: "read"})
+	conn := b.getConn()
+	defer b.release(conn)
+	if _, err = conn.Do("RPUSH", b.key, text); err != nil {
+		t.Fatal(err)
+	}
+	if invalidation == CacheRevision {
+		testLoad("load after RPUSH", true)
+	}
+	if _, err = conn.Do("INCR", b.revisionKey()); err != nil {
+		t.Fatal(err)
+	}
+	enforcer = testLoad("load after INCR", false)
+	testGetPolicy(t, enforcer, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"dave", "data4", "read"}})
+}
+
+func TestCache(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_cached"), WithCache(CacheRevision))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	testSaveLoad(t, a)
+	testAutoSave(t, a)
+	testFilteredPolicy(t, a)
+	testAddPolicies(t, a)
+	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testUpdatePolicies(t, a)
+	testUpdateFilteredPolicies(t, a)
+
+	testCacheInvalidation(t, CacheRevision)
+	// The test server does not send keyspace notifications, so the cache
+	// falls back to the revision counter.
+	testCacheInvalidation(t, CacheKeyspaceNotifications)
+}
+
+func TestRevisionCounter(t *testing.T) {
+	for _, revision := range []bool{false, true} {
+		options := []Option{WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_revision")}
+		if revision {
+			options = append(options, WithRevisionCounter())
+		}
+		a, err := NewAdapterWithOption(options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := a.getConn()
+		if _, err = conn.Do("DEL", a.key, a.revisionKey()); err != nil {
+			t.Fatal(err)
+		}
+
+		testRevision := func(name string, expected int64) {
+			t.Helper()
+			current, err := redis.Int64(conn.Do("GET", a.revisionKey()))
+			if err == redis.ErrNil {
+				err = nil
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !revision {
+				expected = 0
+			}
+			if current != expected {
+				t.Errorf("%s: revision %d, supposed to be %d", name, current, expected)
+			}
+		}
+
+		rule := []string{"alice", "data1", "read"}
+		if err = a.AddPolicy("p", "p", rule); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("AddPolicy", 1)
+		// Writes that change nothing keep the revision.
+		if err = a.AddPolicy("p", "p", rule); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("AddPolicy of a stored rule", 1)
+		if err = a.RemoveFilteredPolicy("p", "p", 0, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("RemoveFilteredPolicy without a match", 1)
+		if err = a.UpdatePolicy("p", "p", []string{"bob", "data1", "read"}, rule); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("UpdatePolicy without a match", 1)
+		if _, err = a.Deduplicate(); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("Deduplicate without duplicates", 1)
+		if err = a.RemovePolicy("p", "p", rule); err != nil {
+			t.Fatal(err)
+		}
+		testRevision("RemovePolicy", 2)
+
+		a.release(conn)
+		a.Close()
+	}
+}
+
+// TestCacheKeyspaceNotifications pretends that the test server sends the
+// keyspace notifications, which are published by hand.
+func TestCacheKeyspaceNotifications(t *testing.T) {
+	check := checkKeyspaceNotifications
+	checkKeyspaceNotifications = func(redis.Conn) error { return nil }
+	defer func() { checkKeyspaceNotifications = check }()
+
+	m := &recordedMetrics{}
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_notified"),
+		WithCache(CacheKeyspaceNotifications), WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	waitFor := func(name string, done func(c *policyCache) bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			a.cache.mu.Lock()
+			ok := done(a.cache)
+			a.cache.mu.Unlock()
+			if ok {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", name)
+			}
+		}
+	}
+	testLoad := func(name string, cached bool) {
+		t.Helper()
+		m.take()
+		if _, err := casbin.NewEnforcer("examples/rbac_model.conf", a); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		stats := m.take()
+		if len(stats) != 1 || (stats[0].RulesRead == 0) != cached {
+			t.Errorf("%s: %+v, supposed to be served from the cache: %t", name, stats, cached)
+		}
+	}
+
+	waitFor("the subscription", func(c *policyCache) bool { return c.subscribed })
+	initPolicy(t, a)
+	testLoad("load after initPolicy", true)
+
+	// While subscribed, the revision is not compared.
+	conn := a.getConn()
+	defer a.release(conn)
+	if _, err = conn.Do("INCR", a.revisionKey()); err != nil {
+		t.Fatal(err)
+	}
+	testLoad("load after INCR", true)
+
+	if _, err = conn.Do("PUBLISH", "__keyspace@0__:"+a.key, "rpush"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("the notification", func(c *policyCache) bool { return !c.cached })
+	testLoad("load after the notification", false)
+	testLoad("load after load", true)
+}
+
+func TestNotifiesKeyspace(t *testing.T) {
+	for flags, expected := range map[string]bool{
+		"":     false,
+		"Klg":  true,
+		"glK":  true,
+		"KA":   true,
+		"Kl":   false,
+		"Elg":  false,
+		"KEA":  true,
+		"AKEx": true,
+	} {
+		if notifiesKeyspace(flags) != expected {
+			t.Errorf("notifiesKeyspace(%q) supposed to be %t", flags, expected)
+		}
+	}
+}
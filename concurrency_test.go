@@ -0,0 +1,83 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// TestConcurrency is meant to be run with the race detector:
+//
+//	go test -race -run TestConcurrency
+func TestConcurrency(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_concurrency"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	initPolicy(t, a)
+
+	e, err := casbin.NewSyncedEnforcer("examples/rbac_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 8
+	const rules = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rules)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rules; j++ {
+				rule := []string{fmt.Sprintf("user%d", i), fmt.Sprintf("data%d", j), "read"}
+				if err := a.AddPolicy("p", "p", rule); err != nil {
+					errs <- err
+					return
+				}
+				if j%2 == 0 {
+					if err := a.RemovePolicy("p", "p", rule); err != nil {
+						errs <- err
+						return
+					}
+				}
+				if err := a.LoadFilteredPolicy(e.GetModel().Copy(), &Filter{V0: []string{rule[0]}}); err != nil {
+					errs <- err
+					return
+				}
+				_ = a.IsFiltered()
+				if _, err := e.Enforce("alice", "data1", "read"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if err = e.LoadPolicy(); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 5+workers*rules/2)
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func testExportCSV(t *testing.T, a *Adapter) {
+	initPolicy(t, a)
+
+	var buf bytes.Buffer
+	if err := a.ExportCSV(&buf, &Filter{V0: []string{"alice", "bob"}}); err != nil {
+		t.Fatalf("ExportCSV failed, err: %v", err)
+	}
+	expected := "p, alice, data1, read\np, bob, data2, write\ng, alice, data2_admin\n"
+	if buf.String() != expected {
+		t.Errorf("ExportCSV: %q, supposed to be %q", buf.String(), expected)
+	}
+
+	// The full export must be readable by casbin's file adapter.
+	buf.Reset()
+	if err := a.ExportCSV(&buf, nil); err != nil {
+		t.Fatalf("ExportCSV failed, err: %v", err)
+	}
+	f, err := os.CreateTemp("", "casbin_policy_*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+}
+
+func testImportCSV(t *testing.T, a *Adapter) {
+	initPolicy(t, a)
+
+	var err error
+	logErr := func(action string) {
+		if err != nil {
+			t.Fatalf("test action[%s] failed, err: %v", action, err)
+		}
+	}
+
+	f, err := os.Open("examples/rbac_policy.csv")
+	logErr("Open")
+	defer f.Close()
+
+	// Merging the same rules again must not duplicate them.
+	err = a.ImportCSV(f, ImportModeMerge)
+	logErr("ImportCSV")
+	err = a.ImportCSV(strings.NewReader("p, carol, data3, read\n\n# comment\np, \"dave, jr\", data3, \"say \"\"hi\"\"\"\n"), ImportModeMerge)
+	logErr("ImportCSV2")
+
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	logErr("NewEnforcer")
+	testGetPolicyWithoutOrder(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"carol", "data3", "read"}, {"dave, jr", "data3", `say "hi"`}})
+
+	// Quoted fields survive an export and import round trip.
+	var buf bytes.Buffer
+	err = a.ExportCSV(&buf, &Filter{V0: []string{"dave, jr"}})
+	logErr("ExportCSV")
+	err = a.ImportCSV(&buf, ImportModeReplace)
+	logErr("ImportCSV3")
+	err = e.LoadPolicy()
+	logErr("LoadPolicy")
+	testGetPolicyWithoutOrder(t, e, [][]string{{"dave, jr", "data3", `say "hi"`}})
+
+	// Trailing spaces are trimmed from the lines, not from quoted fields.
+	err = a.ImportCSV(strings.NewReader("p, erin, data4, \"read \"\np, frank, data5, write  \t\n"), ImportModeReplace)
+	logErr("ImportCSV4")
+	buf.Reset()
+	err = a.ExportCSV(&buf, nil)
+	logErr("ExportCSV2")
+	err = a.ImportCSV(&buf, ImportModeReplace)
+	logErr("ImportCSV5")
+	err = e.LoadPolicy()
+	logErr("LoadPolicy2")
+	testGetPolicy(t, e, [][]string{{"erin", "data4", "read "}, {"frank", "data5", "write"}})
+
+	err = a.ImportCSV(strings.NewReader("p\n"), ImportModeReplace)
+	if err == nil {
+		t.Error("ImportCSV should fail on a rule without values")
+	}
+	err = a.ImportCSV(strings.NewReader("x, alice, data1, read\n"), ImportModeReplace)
+	if err == nil {
+		t.Error("ImportCSV should fail on an unknown section")
+	}
+	err = a.ImportCSV(strings.NewReader(""), ImportMode(2))
+	if err == nil {
+		t.Error("ImportCSV should fail on an invalid mode")
+	}
+
+	// The staged rules never expire.
+	conn := a.getConn()
+	defer a.release(conn)
+	ttl, err := redis.Int(conn.Do("TTL", a.key))
+	logErr("TTL")
+	if ttl != -1 {
+		t.Errorf("TTL of the rules: %d, supposed to be -1", ttl)
+	}
+	keys, err := redis.Strings(conn.Do("KEYS", a.key+":import*"))
+	logErr("KEYS")
+	if len(keys) != 0 {
+		t.Errorf("Staging keys left: %v", keys)
+	}
+	err = e.LoadPolicy()
+	logErr("LoadPolicy3")
+	testGetPolicy(t, e, [][]string{{"erin", "data4", "read "}, {"frank", "data5", "write"}})
+
+	err = a.ImportCSV(strings.NewReader(""), ImportModeReplace)
+	logErr("ImportCSV6")
+	err = e.LoadPolicy()
+	logErr("LoadPolicy4")
+	testGetPolicyWithoutOrder(t, e, [][]string{})
+}
+
+func TestCSV(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExportCSV(t, a)
+	testImportCSV(t, a)
+}
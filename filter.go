@@ -0,0 +1,222 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FieldPType identifies the ptype in a Condition, the values are identified
+// by their index, 0 for V0 to 5 for V5.
+const FieldPType = -1
+
+type conditionKind int
+
+const (
+	conditionIn conditionKind = iota
+	conditionPrefix
+	conditionRegexp
+)
+
+// Condition constrains one field of a rule in a Filter. Conditions are
+// created with In, NotIn, Prefix, Glob, Regexp and Not.
+type Condition struct {
+	field  int
+	kind   conditionKind
+	values []string
+	re     *regexp.Regexp
+	negate bool
+}
+
+// In matches the rules whose field equals one of the values.
+func In(field int, values ...string) Condition {
+	return Condition{field: field, kind: conditionIn, values: values}
+}
+
+// NotIn matches the rules whose field equals none of the values.
+func NotIn(field int, values ...string) Condition {
+	return Not(In(field, values...))
+}
+
+// Prefix matches the rules whose field starts with prefix.
+func Prefix(field int, prefix string) Condition {
+	return Condition{field: field, kind: conditionPrefix, values: []string{prefix}}
+}
+
+// Glob matches the rules whose field matches pattern, in which '*' matches
+// any sequence of characters, including '/', and '?' matches any single
+// character.
+func Glob(field int, pattern string) Condition {
+	var b strings.Builder
+	b.WriteString(`^`)
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return Regexp(field, regexp.MustCompile(b.String()))
+}
+
+// Regexp matches the rules whose field matches re. Like re.MatchString, it
+// matches any substring unless the expression is anchored.
+func Regexp(field int, re *regexp.Regexp) Condition {
+	return Condition{field: field, kind: conditionRegexp, re: re}
+}
+
+// Not matches the rules that c does not match.
+func Not(c Condition) Condition {
+	c.negate = !c.negate
+	return c
+}
+
+func (c Condition) match(line *CasbinRule) bool {
+	value, ok := line.field(c.field)
+	if !ok {
+		return c.negate
+	}
+
+	var matched bool
+	switch c.kind {
+	case conditionIn:
+		for _, v := range c.values {
+			if value == v {
+				matched = true
+				break
+			}
+		}
+	case conditionPrefix:
+		matched = strings.HasPrefix(value, c.values[0])
+	case conditionRegexp:
+		matched = c.re.MatchString(value)
+	}
+	return matched != c.negate
+}
+
+// field returns the ptype for FieldPType or the value at index.
+func (c *CasbinRule) field(index int) (string, bool) {
+	switch index {
+	case FieldPType:
+		return c.PType, true
+	case 0:
+		return c.V0, true
+	case 1:
+		return c.V1, true
+	case 2:
+		return c.V2, true
+	case 3:
+		return c.V3, true
+	case 4:
+		return c.V4, true
+	case 5:
+		return c.V5, true
+	default:
+		return "", false
+	}
+}
+
+// simple reports whether the filter only has lists of exact values, which
+// the function library can match on the server.
+func (filter *Filter) simple() bool {
+	return len(filter.Where) == 0 && len(filter.Or) == 0 &&
+		(filter.P == nil || filter.P.simple()) && (filter.G == nil || filter.G.simple())
+}
+
+// ruleMatcher matches the stored rules against a Filter.
+type ruleMatcher struct {
+	filter *Filter
+	// re matches the encoded rules that have one of the exact values of
+	// each field. It rejects most rules before they are decoded.
+	re *regexp.Regexp
+	or []*ruleMatcher
+	p  *ruleMatcher
+	g  *ruleMatcher
+}
+
+func newRuleMatcher(filter *Filter) *ruleMatcher {
+	m := &ruleMatcher{
+		filter: filter,
+		re:     regexp.MustCompile(filterToRegexPattern(filter)),
+	}
+	for _, f := range filter.Or {
+		m.or = append(m.or, newRuleMatcher(f))
+	}
+	if filter.P != nil {
+		m.p = newRuleMatcher(filter.P)
+	}
+	if filter.G != nil {
+		m.g = newRuleMatcher(filter.G)
+	}
+	return m
+}
+
+// matchText reports whether the encoded rule may match the filter.
+func (m *ruleMatcher) matchText(text []byte) bool {
+	return m.re.Match(text)
+}
+
+// match reports whether the decoded rule matches the filter.
+func (m *ruleMatcher) match(line *CasbinRule) bool {
+	if !m.matchFields(line) {
+		return false
+	}
+	for _, c := range m.filter.Where {
+		if !c.match(line) {
+			return false
+		}
+	}
+	if section := m.section(line.PType); section != nil && !section.match(line) {
+		return false
+	}
+	if len(m.or) == 0 {
+		return true
+	}
+	for _, or := range m.or {
+		if or.match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFields reports whether the fields of the decoded rule have one of the
+// exact values of the filter.
+func (m *ruleMatcher) matchFields(line *CasbinRule) bool {
+	lists := [][]string{m.filter.PType, m.filter.V0, m.filter.V1, m.filter.V2, m.filter.V3, m.filter.V4, m.filter.V5}
+	for i, values := range lists {
+		if len(values) > 0 && !In(i-1, values...).match(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// section returns the matcher of the section of ptype, if any.
+func (m *ruleMatcher) section(ptype string) *ruleMatcher {
+	switch {
+	case strings.HasPrefix(ptype, "p"):
+		return m.p
+	case strings.HasPrefix(ptype, "g"):
+		return m.g
+	default:
+		return nil
+	}
+}
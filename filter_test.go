@@ -0,0 +1,182 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestConditions(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	initPolicy(t, a)
+	if err = a.AddPolicies("p", "p", [][]string{
+		{"carol", "/api/v1/users", "GET"},
+		{"carol", "/api/v1/users/1", "DELETE"},
+		{"dave", "/api/v2/users", "GET"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		filter   *Filter
+		expected [][]string
+	}{
+		{"prefix", &Filter{Where: []Condition{Prefix(1, "/api/v1/")}},
+			[][]string{{"p", "carol", "/api/v1/users", "GET"}, {"p", "carol", "/api/v1/users/1", "DELETE"}}},
+		{"glob", &Filter{Where: []Condition{Glob(1, "/api/v?/users")}},
+			[][]string{{"p", "carol", "/api/v1/users", "GET"}, {"p", "dave", "/api/v2/users", "GET"}}},
+		{"glob crossing segments", &Filter{Where: []Condition{Glob(1, "/api/*/1")}},
+			[][]string{{"p", "carol", "/api/v1/users/1", "DELETE"}}},
+		{"regexp", &Filter{Where: []Condition{Regexp(2, regexp.MustCompile(`^(GET|read)$`))}},
+			[][]string{{"p", "alice", "data1", "read"}, {"p", "data2_admin", "data2", "read"}, {"p", "carol", "/api/v1/users", "GET"}, {"p", "dave", "/api/v2/users", "GET"}}},
+		{"not in", &Filter{PType: []string{"p"}, Where: []Condition{NotIn(0, "alice", "bob", "data2_admin", "carol")}},
+			[][]string{{"p", "dave", "/api/v2/users", "GET"}}},
+		{"not", &Filter{V0: []string{"carol"}, Where: []Condition{Not(Glob(1, "*/1"))}},
+			[][]string{{"p", "carol", "/api/v1/users", "GET"}}},
+		{"ptype", &Filter{Where: []Condition{In(FieldPType, "g")}},
+			[][]string{{"g", "alice", "data2_admin"}}},
+		{"or", &Filter{Or: []*Filter{{V0: []string{"bob"}}, {Where: []Condition{Prefix(1, "/api/v2")}}}},
+			[][]string{{"p", "bob", "data2", "write"}, {"p", "dave", "/api/v2/users", "GET"}}},
+		{"and or", &Filter{V2: []string{"GET"}, Or: []*Filter{{V0: []string{"bob"}}, {V0: []string{"carol"}}}},
+			[][]string{{"p", "carol", "/api/v1/users", "GET"}}},
+	} {
+		rules, err := a.GetPolicies(context.Background(), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rules, tc.expected) {
+			t.Errorf("%s: %v, supposed to be %v", tc.name, rules, tc.expected)
+		}
+	}
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	err = a.LoadFilteredPolicy(e.GetModel(), &Filter{Or: []*Filter{
+		{PType: []string{"g"}},
+		{Where: []Condition{Prefix(1, "/api/v1/")}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"carol", "/api/v1/users", "GET"}, {"carol", "/api/v1/users/1", "DELETE"}})
+	if len(e.GetGroupingPolicy()) != 1 {
+		t.Errorf("Grouping policy: %v, supposed to have 1 rule", e.GetGroupingPolicy())
+	}
+}
+
+func TestSectionFilter(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_section_filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err = a.ImportCSV(strings.NewReader(`p, alice, domain1, data1, read
+p, bob, domain2, data2, read
+g, alice, admin, domain1
+g, bob, admin, domain2
+`), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	// All grouping rules, but only the policy rules of domain1.
+	filter := &Filter{P: &Filter{V1: []string{"domain1"}}}
+	expected := [][]string{{"p", "alice", "domain1", "data1", "read"}, {"g", "alice", "admin", "domain1"}, {"g", "bob", "admin", "domain2"}}
+	rules, err := a.GetPolicies(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("GetPolicies: %v, supposed to be %v", rules, expected)
+	}
+
+	// The function library filters the sections on the server the same way.
+	text, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := a.getConn()
+	values, err := redis.Strings(a.evalScript(conn, loadFilteredPolicyScript, a.key, text))
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(expected) {
+		t.Errorf("load_filtered_policy: %v, supposed to return %d rules", values, len(expected))
+	}
+
+	e, _ := casbin.NewEnforcer("examples/rbac_with_domains_model.conf")
+	err = a.LoadFilteredPolicy(e.GetModel(), &Filter{
+		P: &Filter{V1: []string{"domain2"}},
+		G: &Filter{Where: []Condition{Not(In(2, "domain2"))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"bob", "domain2", "data2", "read"}})
+	if g := e.GetGroupingPolicy(); !reflect.DeepEqual(g, [][]string{{"alice", "admin", "domain1"}}) {
+		t.Errorf("Grouping policy: %v, supposed to be the rule of domain1", g)
+	}
+}
+
+func TestFilterEscapedValues(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_filter_escaped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	// JSON escapes ", &, < and > in the stored rules.
+	if err = a.ImportCSV(strings.NewReader(`p, "say ""hi""", data1, read
+p, <ops>, data1, read
+p, R&D, data1, read
+p, R&D, data2, read
+p, alice, data1, read
+`), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	if err = a.LoadFilteredPolicy(e.GetModel(), &Filter{PType: []string{"p"}, V0: []string{`say "hi"`, "<ops>"}}); err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{`say "hi"`, "data1", "read"}, {"<ops>", "data1", "read"}})
+
+	for _, value := range []string{`say "hi"`, "<ops>"} {
+		if err = a.RemoveFilteredPolicy("p", "p", 0, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testStoredCount(t, a, 3)
+
+	removed, err := a.UpdateFilteredPolicies("p", "p", [][]string{{"R&D", "data3", "read"}}, 0, "R&D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("UpdateFilteredPolicies removed %v, supposed to be the 2 rules of R&D", removed)
+	}
+	testStoredCount(t, a, 2)
+}
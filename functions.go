@@ -0,0 +1,86 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// functionLibraryName is derived from the scripts, so that adapters of
+// different versions sharing a server install separate libraries instead of
+// replacing each other's functions.
+var functionLibraryName = libraryName(luaScripts)
+
+func libraryName(scripts []*luaScript) string {
+	h := sha1.New()
+	for _, s := range scripts {
+		fmt.Fprintf(h, "%s %d %d\n%s\n", s.name, s.keyCount, len(s.src), s.src)
+	}
+	return fmt.Sprintf("casbin_%x", h.Sum(nil)[:6])
+}
+
+// WithFunctions makes the adapter install its scripts as a Redis Functions
+// library and invoke them with FCALL instead of EVAL. Servers older than
+// Redis 7 do not support functions, the adapter falls back to EVAL there.
+func WithFunctions() Option {
+	return func(a *Adapter) {
+		a.useFunctions = true
+	}
+}
+
+func (s *luaScript) functionName() string {
+	return functionLibraryName + "_" + s.name
+}
+
+// functionLibrary returns the source of the library registering every script
+// as a function. The callbacks name their parameters KEYS and ARGV so that
+// the script bodies can be shared with the EVAL mode.
+func functionLibrary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!lua name=%s\n", functionLibraryName)
+	for _, s := range luaScripts {
+		fmt.Fprintf(&b, "redis.register_function('%s', function(KEYS, ARGV)\n%s\nend)\n", s.functionName(), s.src)
+	}
+	return b.String()
+}
+
+func loadFunctionLibrary(conn redis.Conn) error {
+	_, err := conn.Do("FUNCTION", "LOAD", "REPLACE", functionLibrary())
+	return err
+}
+
+// callFunction invokes the script with FCALL. If the library is missing,
+// e.g. after FUNCTION FLUSH or a restart without persistence, it is
+// installed again.
+func callFunction(conn redis.Conn, s *luaScript, keysAndArgs ...interface{}) (interface{}, error) {
+	args := redis.Args{}.Add(s.functionName(), s.keyCount).Add(keysAndArgs...)
+	reply, err := conn.Do("FCALL", args...)
+	if e, ok := err.(redis.Error); ok && strings.Contains(string(e), "Function not found") {
+		if err = loadFunctionLibrary(conn); err != nil {
+			return nil, err
+		}
+		reply, err = conn.Do("FCALL", args...)
+	}
+	return reply, err
+}
+
+func isUnknownCommand(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && strings.HasPrefix(string(e), "ERR unknown command")
+}
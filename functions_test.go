@@ -0,0 +1,75 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func TestFunctions(t *testing.T) {
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_functions"), WithFunctions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.fcall {
+		t.Log("Redis Functions are not supported by the server, testing the EVAL fallback")
+	}
+
+	testSaveLoad(t, a)
+	testAutoSave(t, a)
+	testFilteredPolicy(t, a)
+	testAddPolicies(t, a)
+	testRemovePolicies(t, a)
+	testAddPolicyIdempotent(t, a)
+	testDeduplicate(t, a)
+	testUpdatePolicies(t, a)
+	testUpdateFilteredPolicies(t, a)
+	testUpdateFilteredPoliciesCases(t, a)
+
+	if !a.fcall {
+		return
+	}
+
+	// The library is installed again after it was flushed.
+	conn := a.getConn()
+	_, err = conn.Do("FUNCTION", "FLUSH")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initPolicy(t, a)
+	if err = a.RemoveFilteredPolicy("p", "p", 0, "data2_admin"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}})
+}
+
+func TestLibraryName(t *testing.T) {
+	if !regexp.MustCompile(`^casbin_[0-9a-f]{12}$`).MatchString(functionLibraryName) {
+		t.Errorf("Library name %q is not a valid name", functionLibraryName)
+	}
+
+	// Changing a script installs a separate library.
+	changed := *addPoliciesScript
+	changed.keyCount = 1
+	scripts := append([]*luaScript{&changed}, luaScripts[1:]...)
+	if libraryName(scripts) == functionLibraryName {
+		t.Error("The library name should change with the scripts")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// HealthStatus is the result of HealthCheck.
+type HealthStatus struct {
+	// Latency is the round trip time of a PING.
+	Latency time.Duration
+	// KeyType is the type of the policy key, "none" if it does not exist yet.
+	KeyType string
+	// RuleCount is the number of stored rules.
+	RuleCount int
+	// ScriptsLoaded reports whether the scripts or the function library are
+	// available on the server. Missing scripts are loaded again on their next
+	// use, so they do not make the check fail.
+	ScriptsLoaded bool
+}
+
+// getConnContext gets a connection whose commands can be run with
+// redis.DoContext.
+func (a *Adapter) getConnContext(ctx context.Context) (redis.Conn, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return nil, ErrAdapterClosed
+	}
+	conn, err := a._pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.wrapConn(conn), nil
+}
+
+// Ping checks that the server can be reached and the adapter is
+// authenticated.
+func (a *Adapter) Ping(ctx context.Context) (err error) {
+	op := a.startOperationContext(ctx, "Ping")
+	defer func() { op.end(err) }()
+
+	conn, err := a.getConnContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.release(conn)
+
+	_, err = redis.DoContext(conn, ctx, "PING")
+	return err
+}
+
+// HealthCheck checks that the server can be reached, the adapter is
+// authenticated and the policy key holds a list. The returned status is
+// filled as far as the check got, even if it failed.
+func (a *Adapter) HealthCheck(ctx context.Context) (_ *HealthStatus, err error) {
+	op := a.startOperationContext(ctx, "HealthCheck")
+	defer func() { op.end(err) }()
+
+	status := &HealthStatus{}
+
+	conn, err := a.getConnContext(ctx)
+	if err != nil {
+		return status, err
+	}
+	defer a.release(conn)
+
+	start := time.Now()
+	if _, err = redis.DoContext(conn, ctx, "PING"); err != nil {
+		return status, err
+	}
+	status.Latency = time.Since(start)
+
+	status.KeyType, err = redis.String(redis.DoContext(conn, ctx, "TYPE", a.key))
+	if err != nil {
+		return status, err
+	}
+	if status.KeyType != "list" && status.KeyType != "none" {
+		return status, fmt.Errorf("policy key %q holds a %s instead of a list", a.key, status.KeyType)
+	}
+
+	status.RuleCount, err = redis.Int(redis.DoContext(conn, ctx, "LLEN", a.key))
+	if err != nil {
+		return status, err
+	}
+
+	status.ScriptsLoaded, err = a.scriptsLoaded(ctx, conn)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+func (a *Adapter) scriptsLoaded(ctx context.Context, conn redis.Conn) (bool, error) {
+	if a.fcall {
+		libraries, err := redis.Values(redis.DoContext(conn, ctx, "FUNCTION", "LIST", "LIBRARYNAME", functionLibraryName))
+		if err != nil {
+			return false, err
+		}
+		return len(libraries) > 0, nil
+	}
+
+	args := redis.Args{}.Add("EXISTS")
+	for _, s := range luaScripts {
+		args = args.Add(s.script.Hash())
+	}
+	exists, err := redis.Ints(redis.DoContext(conn, ctx, "SCRIPT", args...))
+	if err != nil {
+		return false, err
+	}
+	for _, v := range exists {
+		if v != 1 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
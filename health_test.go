@@ -0,0 +1,97 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err = a.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	a.dropTable()
+	status, err := a.HealthCheck(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.KeyType != "none" || status.RuleCount != 0 || !status.ScriptsLoaded {
+		t.Errorf("HealthCheck: %+v, supposed to report a missing key", status)
+	}
+
+	initPolicy(t, a)
+	status, err = a.HealthCheck(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.KeyType != "list" || status.RuleCount != 5 || !status.ScriptsLoaded || status.Latency <= 0 {
+		t.Errorf("HealthCheck: %+v, supposed to report 5 rules", status)
+	}
+
+	// Flushed scripts are reported, but are no failure.
+	conn := a.getConn()
+	_, err = conn.Do("SCRIPT", "FLUSH")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = a.HealthCheck(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ScriptsLoaded {
+		t.Errorf("HealthCheck: %+v, supposed to report missing scripts", status)
+	}
+	if err = a.loadScripts(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The policy key holds something else than a list.
+	conn = a.getConn()
+	_, err = conn.Do("SET", a.key, "casbin")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = a.HealthCheck(ctx)
+	if err == nil || status.KeyType != "string" {
+		t.Errorf("HealthCheck: %+v, %v, supposed to fail on a string key", status, err)
+	}
+	a.dropTable()
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err = a.Ping(canceled); err == nil {
+		t.Error("Ping should fail with a canceled context")
+	}
+
+	if err = a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Ping(ctx); err != ErrAdapterClosed {
+		t.Errorf("Ping: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+	if _, err = a.HealthCheck(ctx); err != ErrAdapterClosed {
+		t.Errorf("HealthCheck: %v, supposed to be %v", err, ErrAdapterClosed)
+	}
+}
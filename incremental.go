@@ -0,0 +1,151 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/casbin/casbin/v2/model"
+)
+
+// LoadIncrementalFilteredPolicy loads the rules that match the filter into a
+// model that already holds rules of other filters, e.g. to load the policy
+// of a tenant on demand. Rules that are already loaded are skipped. When
+// grouping rules are loaded into the model of an enforcer, its role links
+// must be rebuilt with Enforcer.BuildRoleLinks.
+func (a *Adapter) LoadIncrementalFilteredPolicy(model model.Model, filter interface{}) (err error) {
+	op := a.startOperation("LoadIncrementalFilteredPolicy")
+	defer func() { op.end(err) }()
+
+	return a.loadFilter(op, model, filter, true)
+}
+
+// UnloadFilteredPolicy removes the rules that match the filter from the
+// model, except those that match another loaded filter, and forgets the
+// filter, e.g. to drop the policy of a tenant. When grouping rules are
+// removed from the model of an enforcer, its role links must be rebuilt with
+// Enforcer.BuildRoleLinks.
+func (a *Adapter) UnloadFilteredPolicy(model model.Model, filter interface{}) (err error) {
+	op := a.startOperation("UnloadFilteredPolicy")
+	defer func() { op.end(err) }()
+
+	f, err := toFilter(filter)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return errors.New("cannot unload a nil filter")
+	}
+
+	a.mu.Lock()
+	var kept []*ruleMatcher
+	filters := a.loadedFilters[:0:0]
+	for _, loaded := range a.loadedFilters {
+		if reflect.DeepEqual(loaded, f) {
+			continue
+		}
+		filters = append(filters, loaded)
+		kept = append(kept, newRuleMatcher(loaded))
+	}
+	a.loadedFilters = filters
+	a.mu.Unlock()
+
+	m := newRuleMatcher(f)
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range model[sec] {
+			var rules [][]string
+			for _, rule := range ast.Policy {
+				line, err := savePolicyLine(sec, ptype, rule)
+				if err != nil {
+					return err
+				}
+				if m.match(&line) && !matchAny(kept, &line) {
+					rules = append(rules, rule)
+				}
+			}
+			if len(rules) > 0 {
+				// Only the model is modified, nothing is written to the
+				// server.
+				model.RemovePolicies(sec, ptype, rules)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadedFilters returns the filters whose rules were loaded since the whole
+// policy was last loaded.
+func (a *Adapter) LoadedFilters() []*Filter {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]*Filter(nil), a.loadedFilters...)
+}
+
+// loadFilter loads the rules that match the filter and records the filter
+// as loaded, in addition to the loaded filters if incremental is true.
+func (a *Adapter) loadFilter(op *operation, model model.Model, filter interface{}, incremental bool) error {
+	f, err := toFilter(filter)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return a.loadPolicy(op, model)
+	}
+
+	err = a.loadFilteredPolicy(op, model, f)
+	// A lenient load that skipped rules still loaded the filtered policy.
+	var skipped *MalformedRulesError
+	if err != nil && !errors.As(err, &skipped) {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.isFiltered = true
+	if !incremental {
+		a.loadedFilters = nil
+	}
+	for _, loaded := range a.loadedFilters {
+		if reflect.DeepEqual(loaded, f) {
+			return err
+		}
+	}
+	a.loadedFilters = append(a.loadedFilters, f)
+	return err
+}
+
+func toFilter(filter interface{}) (*Filter, error) {
+	switch f := filter.(type) {
+	case nil:
+		return nil, nil
+	case *Filter:
+		return f, nil
+	case Filter:
+		return &f, nil
+	default:
+		return nil, fmt.Errorf("invalid filter type")
+	}
+}
+
+func matchAny(matchers []*ruleMatcher, line *CasbinRule) bool {
+	for _, m := range matchers {
+		if m.match(line) {
+			return true
+		}
+	}
+	return false
+}
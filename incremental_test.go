@@ -0,0 +1,108 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func domainFilter(domain string) *Filter {
+	return &Filter{
+		P: &Filter{V1: []string{domain}},
+		G: &Filter{V2: []string{domain}},
+	}
+}
+
+func TestIncrementalFilteredPolicy(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_incremental")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err = a.ImportCSV(strings.NewReader(`p, admin, domain1, data1, read
+p, admin, domain2, data2, read
+p, admin, domain3, data3, read
+g, alice, admin, domain1
+g, bob, admin, domain2
+`), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := casbin.NewEnforcer("examples/rbac_with_domains_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEnforce := func(sub string, dom string, obj string, expected bool) {
+		t.Helper()
+		if ok, _ := e.Enforce(sub, dom, obj, "read"); ok != expected {
+			t.Errorf("%s, %s, %s: %t, supposed to be %t", sub, dom, obj, ok, expected)
+		}
+	}
+
+	if err = e.LoadFilteredPolicy(domainFilter("domain1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.LoadIncrementalFilteredPolicy(e.GetModel(), domainFilter("domain2")); err != nil {
+		t.Fatal(err)
+	}
+	// Loading a loaded filter again neither duplicates its rules nor the filter.
+	if err = a.LoadIncrementalFilteredPolicy(e.GetModel(), domainFilter("domain2")); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.BuildRoleLinks(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(a.LoadedFilters()); n != 2 {
+		t.Errorf("Loaded filters: %d, supposed to be 2", n)
+	}
+	testGetPolicyWithoutOrder(t, e, [][]string{{"admin", "domain1", "data1", "read"}, {"admin", "domain2", "data2", "read"}})
+	testEnforce("alice", "domain1", "data1", true)
+	testEnforce("bob", "domain2", "data2", true)
+
+	if err = a.UnloadFilteredPolicy(e.GetModel(), domainFilter("domain1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.BuildRoleLinks(); err != nil {
+		t.Fatal(err)
+	}
+	if filters := a.LoadedFilters(); len(filters) != 1 || filters[0].P.V1[0] != "domain2" {
+		t.Errorf("Loaded filters: %v, supposed to be the filter of domain2", filters)
+	}
+	testGetPolicy(t, e, [][]string{{"admin", "domain2", "data2", "read"}})
+	testEnforce("alice", "domain1", "data1", false)
+	testEnforce("bob", "domain2", "data2", true)
+	if !e.IsFiltered() {
+		t.Error("The policy should still be filtered")
+	}
+
+	// Loading a filter with LoadFilteredPolicy replaces the loaded filters,
+	// even into a model that holds rules.
+	if err = a.LoadFilteredPolicy(e.GetModel(), domainFilter("domain3")); err != nil {
+		t.Fatal(err)
+	}
+	if filters := a.LoadedFilters(); len(filters) != 1 || filters[0].P.V1[0] != "domain3" {
+		t.Errorf("Loaded filters: %v, supposed to be the filter of domain3", filters)
+	}
+
+	if err = e.LoadPolicy(); err != nil {
+		t.Fatal(err)
+	}
+	if e.IsFiltered() || len(a.LoadedFilters()) != 0 {
+		t.Error("Loading the whole policy should forget the loaded filters")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Metrics receives the measurements of the adapter operations.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveOperation is called whenever a public operation of the adapter
+	// returns.
+	ObserveOperation(stats OperationStats)
+}
+
+// OperationStats describes a completed adapter operation.
+type OperationStats struct {
+	// Operation is the name of the Adapter method, e.g. "LoadPolicy".
+	Operation string
+	Duration  time.Duration
+	// Err is the error returned by the operation, if any.
+	Err error
+	// RulesRead is the number of rules received from the server.
+	RulesRead int
+	// RulesWritten is the number of rules inserted, updated or removed.
+	RulesWritten int
+	// BytesRead and BytesWritten are the sizes of the encoded rules received
+	// from and sent to the server.
+	BytesRead    int
+	BytesWritten int
+}
+
+// WithMetrics makes the adapter report every operation to m.
+func WithMetrics(m Metrics) Option {
+	return func(a *Adapter) {
+		a.metrics = m
+	}
+}
+
+// PoolStats returns the statistics of the connection pool.
+func (a *Adapter) PoolStats() redis.PoolStats {
+	return a._pool.Stats()
+}
+
+// operation instruments a single call of a public Adapter method.
+type operation struct {
+	a     *Adapter
+	ctx   context.Context
+	span  Span
+	start time.Time
+	stats OperationStats
+	// write is set by the operations that modify the stored rules.
+	write bool
+}
+
+func (a *Adapter) startOperation(name string) *operation {
+	return a.startOperationContext(context.Background(), name)
+}
+
+// startOperationContext starts an operation of a method that takes a context.
+func (a *Adapter) startOperationContext(ctx context.Context, name string) *operation {
+	op := &operation{
+		a:     a,
+		ctx:   ctx,
+		start: time.Now(),
+		stats: OperationStats{Operation: name},
+	}
+	if a.tracer != nil {
+		op.ctx, op.span = a.tracer.Start(ctx, name)
+		op.span.SetAttribute(AttributeKey, a.key)
+	}
+	return op
+}
+
+func (op *operation) addRead(rules int, bytes int) {
+	op.stats.RulesRead += rules
+	op.stats.BytesRead += bytes
+}
+
+func (op *operation) addWritten(rules int, bytes int) {
+	op.stats.RulesWritten += rules
+	op.stats.BytesWritten += bytes
+}
+
+// end completes the operation with the error it returns.
+func (op *operation) end(err error) {
+	if op.write {
+		op.a.endWrite(op)
+	}
+	op.stats.Duration = time.Since(op.start)
+	op.stats.Err = err
+	if op.span != nil {
+		op.span.SetAttribute(AttributeRulesRead, op.stats.RulesRead)
+		op.span.SetAttribute(AttributeRulesWritten, op.stats.RulesWritten)
+		op.span.End(err)
+	}
+	op.log()
+	if op.a.metrics != nil {
+		op.a.metrics.ObserveOperation(op.stats)
+	}
+}
+
+func payloadSize(texts [][]byte) int {
+	size := 0
+	for _, text := range texts {
+		size += len(text)
+	}
+	return size
+}
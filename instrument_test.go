@@ -0,0 +1,118 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+type recordedMetrics struct {
+	mu    sync.Mutex
+	stats []OperationStats
+}
+
+func (m *recordedMetrics) ObserveOperation(stats OperationStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = append(m.stats, stats)
+}
+
+func (m *recordedMetrics) take() []OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	m.stats = nil
+	return stats
+}
+
+func TestMetrics(t *testing.T) {
+	m := &recordedMetrics{}
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_metrics"), WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	testOperation := func(name string, rulesRead int, rulesWritten int, failed bool) {
+		t.Helper()
+		stats := m.take()
+		if len(stats) != 1 {
+			t.Fatalf("%s: %d operations observed, supposed to be 1", name, len(stats))
+		}
+		s := stats[0]
+		if s.Operation != name || s.RulesRead != rulesRead || s.RulesWritten != rulesWritten || (s.Err != nil) != failed || s.Duration <= 0 {
+			t.Errorf("%s: %+v", name, s)
+		}
+		if (s.RulesRead > 0) != (s.BytesRead > 0) {
+			t.Errorf("%s: %d bytes read for %d rules", name, s.BytesRead, s.RulesRead)
+		}
+	}
+
+	// Start from an empty policy.
+	if err = a.ImportCSV(strings.NewReader(""), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("ImportCSV", 0, 0, false)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", "examples/rbac_policy.csv")
+	if err = a.SavePolicy(e.GetModel()); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("SavePolicy", 0, 5, false)
+
+	e.ClearPolicy()
+	if err = a.LoadPolicy(e.GetModel()); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("LoadPolicy", 5, 0, false)
+
+	if err = a.LoadFilteredPolicy(e.GetModel(), nil); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("LoadFilteredPolicy", 5, 0, false)
+
+	if err = a.AddPolicy("p", "p", []string{"alice", "data1", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("AddPolicy", 0, 0, false)
+
+	if _, err = a.AddPoliciesEx("p", "p", [][]string{{"carol", "data1", "read"}, {"dave", "data1", "read"}}); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("AddPoliciesEx", 0, 2, false)
+
+	if err = a.UpdatePolicy("p", "p", []string{"carol", "data1", "read"}, []string{"carol", "data1", "write"}); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("UpdatePolicy", 0, 1, false)
+
+	if err = a.RemovePolicies("p", "p", [][]string{{"carol", "data1", "write"}, {"dave", "data1", "read"}}); err != nil {
+		t.Fatal(err)
+	}
+	testOperation("RemovePolicies", 0, 2, false)
+
+	if err = a.RemoveFilteredPolicy("x", "p", 0, "alice"); err == nil {
+		t.Fatal("RemoveFilteredPolicy should fail on an unknown section")
+	}
+	testOperation("RemoveFilteredPolicy", 0, 0, true)
+
+	if a.PoolStats().ActiveCount == 0 {
+		t.Error("The pool should have an open connection")
+	}
+}
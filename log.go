@@ -0,0 +1,137 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// maxLoggedValueSize is the number of bytes of a malformed rule that are
+// logged.
+const maxLoggedValueSize = 256
+
+// WithLogger makes the adapter log to logger. Completed operations are logged
+// at debug level, failed operations at error level, and malformed stored
+// rules and slow commands at warn level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Adapter) {
+		a.logger = logger
+	}
+}
+
+// WithSlowCommandThreshold makes the adapter log the commands that take
+// longer than threshold. It has no effect without WithLogger.
+func WithSlowCommandThreshold(threshold time.Duration) Option {
+	return func(a *Adapter) {
+		a.slowCommandThreshold = threshold
+	}
+}
+
+// log logs a completed operation.
+func (op *operation) log() {
+	if op.a.logger == nil {
+		return
+	}
+	level := slog.LevelDebug
+	attrs := []slog.Attr{
+		slog.String("operation", op.stats.Operation),
+		slog.String("key", op.a.key),
+		slog.Duration("duration", op.stats.Duration),
+		slog.Int("rules_read", op.stats.RulesRead),
+		slog.Int("rules_written", op.stats.RulesWritten),
+	}
+	if op.stats.Err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", op.stats.Err))
+	}
+	op.a.logger.LogAttrs(op.ctx, level, "redis adapter operation", attrs...)
+}
+
+// logMalformedRule logs a stored rule that cannot be decoded. The index is
+// the position of the rule in the list, or -1 if it is unknown because the
+// server filtered the rules.
+func (op *operation) logMalformedRule(index int, value interface{}, err error) {
+	if op.a.logger == nil {
+		return
+	}
+	var raw string
+	switch v := value.(type) {
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		raw = slog.AnyValue(v).String()
+	}
+	if len(raw) > maxLoggedValueSize {
+		raw = raw[:maxLoggedValueSize] + "..."
+	}
+	op.a.logger.LogAttrs(op.ctx, slog.LevelWarn, "malformed rule in redis",
+		slog.String("operation", op.stats.Operation),
+		slog.String("key", op.a.key),
+		slog.Int("index", index),
+		slog.String("value", raw),
+		slog.Any("error", err))
+}
+
+// slowLogConn logs the commands that take longer than the slow command
+// threshold.
+type slowLogConn struct {
+	redis.Conn
+	a *Adapter
+}
+
+func (a *Adapter) wrapConn(conn redis.Conn) redis.Conn {
+	if a.logger == nil || a.slowCommandThreshold <= 0 {
+		return conn
+	}
+	return slowLogConn{Conn: conn, a: a}
+}
+
+func (c slowLogConn) logSlow(start time.Time, cmd string) {
+	if d := time.Since(start); d > c.a.slowCommandThreshold {
+		c.a.logger.LogAttrs(context.Background(), slog.LevelWarn, "slow redis command",
+			slog.String("command", cmd),
+			slog.String("key", c.a.key),
+			slog.Duration("duration", d))
+	}
+}
+
+func (c slowLogConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	defer c.logSlow(time.Now(), cmd)
+	return c.Conn.Do(cmd, args...)
+}
+
+func (c slowLogConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	defer c.logSlow(time.Now(), cmd)
+	return redis.DoContext(c.Conn, ctx, cmd, args...)
+}
+
+func (c slowLogConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	defer c.logSlow(time.Now(), cmd)
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, args...)
+}
+
+func (c slowLogConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return redis.ReceiveContext(c.Conn, ctx)
+}
+
+func (c slowLogConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
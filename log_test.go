@@ -0,0 +1,118 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records decodes the logged JSON records and resets the buffer.
+func (b *syncBuffer) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	b.buf.Reset()
+	return records
+}
+
+func findRecord(records []map[string]interface{}, msg string) map[string]interface{} {
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_log"),
+		WithLogger(logger), WithSlowCommandThreshold(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	initPolicy(t, a)
+	buf.records(t)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	if err = a.LoadPolicy(e.GetModel()); err != nil {
+		t.Fatal(err)
+	}
+	records := buf.records(t)
+	if record := findRecord(records, "redis adapter operation"); record == nil ||
+		record["level"] != "DEBUG" || record["operation"] != "LoadPolicy" || record["rules_read"] != 5.0 {
+		t.Errorf("operation record: %v", record)
+	}
+	if record := findRecord(records, "slow redis command"); record == nil || record["command"] != "LRANGE" {
+		t.Errorf("slow command record: %v", record)
+	}
+
+	// Corrupt the third rule.
+	conn := a.getConn()
+	_, err = conn.Do("LSET", a.key, 2, "{not json")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.records(t)
+
+	err = a.LoadPolicy(e.GetModel())
+	if err == nil || !strings.Contains(err.Error(), "index 2") {
+		t.Errorf("LoadPolicy error: %v, supposed to locate the malformed rule", err)
+	}
+	records = buf.records(t)
+	if record := findRecord(records, "malformed rule in redis"); record == nil ||
+		record["level"] != "WARN" || record["index"] != 2.0 || record["value"] != "{not json" {
+		t.Errorf("malformed rule record: %v", record)
+	}
+	if record := findRecord(records, "redis adapter operation"); record == nil || record["level"] != "ERROR" || record["error"] == nil {
+		t.Errorf("operation record: %v", record)
+	}
+}
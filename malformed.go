@@ -0,0 +1,102 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import "fmt"
+
+// MalformedRuleError describes a stored rule that cannot be decoded.
+type MalformedRuleError struct {
+	// Index is the position of the rule in the list, or -1 if it is unknown
+	// because the server filtered the rules.
+	Index int
+	// Value is the stored value as received from the server.
+	Value interface{}
+	Err   error
+}
+
+func (e *MalformedRuleError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("malformed rule: %v", e.Err)
+	}
+	return fmt.Sprintf("malformed rule at index %d: %v", e.Index, e.Err)
+}
+
+func (e *MalformedRuleError) Unwrap() error {
+	return e.Err
+}
+
+// MalformedRulesError is returned by a lenient load that skipped malformed
+// rules, after all other rules were loaded.
+type MalformedRulesError struct {
+	Rules []*MalformedRuleError
+}
+
+func (e *MalformedRulesError) Error() string {
+	if len(e.Rules) == 1 {
+		return "1 rule skipped: " + e.Rules[0].Error()
+	}
+	return fmt.Sprintf("%d rules skipped, first: %v", len(e.Rules), e.Rules[0])
+}
+
+func (e *MalformedRulesError) Unwrap() []error {
+	errs := make([]error, len(e.Rules))
+	for i, rule := range e.Rules {
+		errs[i] = rule
+	}
+	return errs
+}
+
+// WithLenientLoad makes LoadPolicy and LoadFilteredPolicy skip the stored
+// rules that cannot be decoded instead of failing, and pass each of them to
+// handler. If handler is nil, the load returns a *MalformedRulesError after
+// loading all other rules. Note that casbin's Enforcer discards the loaded
+// policy when LoadPolicy returns an error, so enforcers should use a handler.
+func WithLenientLoad(handler func(*MalformedRuleError)) Option {
+	return func(a *Adapter) {
+		a.lenientLoad = true
+		a.malformedRuleHandler = handler
+	}
+}
+
+// malformedRule logs a stored rule that cannot be decoded and returns an
+// error that locates it.
+func (op *operation) malformedRule(index int, value interface{}, err error) *MalformedRuleError {
+	op.logMalformedRule(index, value, err)
+	return &MalformedRuleError{Index: index, Value: value, Err: err}
+}
+
+// skipMalformedRule handles a stored rule that cannot be decoded by a load.
+// It returns nil if the rule is skipped, otherwise the error that aborts the
+// load.
+func (op *operation) skipMalformedRule(skipped *[]*MalformedRuleError, index int, value interface{}, err error) error {
+	e := op.malformedRule(index, value, err)
+	if !op.a.lenientLoad {
+		return e
+	}
+	if op.a.malformedRuleHandler != nil {
+		op.a.malformedRuleHandler(e)
+	} else {
+		*skipped = append(*skipped, e)
+	}
+	return nil
+}
+
+// skippedRulesError returns the error of a load that skipped rules, if any.
+func skippedRulesError(skipped []*MalformedRuleError) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	return &MalformedRulesError{Rules: skipped}
+}
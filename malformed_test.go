@@ -0,0 +1,180 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+)
+
+func corruptRule(t *testing.T, a *Adapter, index int) {
+	t.Helper()
+	conn := a.getConn()
+	defer a.release(conn)
+
+	if _, err := conn.Do("LSET", a.key, index, "{not json"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLenientLoad(t *testing.T) {
+	strict, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_malformed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	initPolicy(t, strict)
+	corruptRule(t, strict, 2)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	err = strict.LoadPolicy(e.GetModel())
+	var malformed *MalformedRuleError
+	if !errors.As(err, &malformed) || malformed.Index != 2 || string(malformed.Value.([]byte)) != "{not json" {
+		t.Fatalf("LoadPolicy error: %v, supposed to be a MalformedRuleError", err)
+	}
+
+	// Without a handler the skipped rules are returned after loading the others.
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_malformed"),
+		WithLenientLoad(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	err = a.LoadPolicy(e.GetModel())
+	var skipped *MalformedRulesError
+	if !errors.As(err, &skipped) || len(skipped.Rules) != 1 || skipped.Rules[0].Index != 2 {
+		t.Fatalf("LoadPolicy error: %v, supposed to be a MalformedRulesError", err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "write"}})
+
+	// A malformed rule never matches a filter.
+	e.ClearPolicy()
+	if err = a.LoadFilteredPolicy(e.GetModel(), &Filter{PType: []string{"p"}}); err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "write"}})
+
+	// With a handler the load succeeds, so an enforcer keeps the loaded rules.
+	var handled []*MalformedRuleError
+	b, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_malformed"),
+		WithLenientLoad(func(e *MalformedRuleError) { handled = append(handled, e) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	e, err = casbin.NewEnforcer("examples/rbac_model.conf", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 1 || handled[0].Index != 2 {
+		t.Errorf("Handled rules: %v, supposed to be the rule at index 2", handled)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "write"}})
+	if ok, _ := e.Enforce("alice", "data2", "write"); !ok {
+		t.Error("alice should inherit the rules of data2_admin")
+	}
+
+	// The queries skip malformed rules the same way.
+	ctx := context.Background()
+	if _, err = strict.GetPolicies(ctx, nil); !errors.As(err, &malformed) {
+		t.Errorf("GetPolicies error: %v, supposed to be a MalformedRuleError", err)
+	}
+	rules, err := a.GetPolicies(ctx, nil)
+	if !errors.As(err, &skipped) || len(rules) != 4 {
+		t.Errorf("GetPolicies: %v, %v, supposed to be 4 rules and a MalformedRulesError", rules, err)
+	}
+	if _, err = strict.CountPolicies(ctx, nil); !errors.As(err, &malformed) {
+		t.Errorf("CountPolicies error: %v, supposed to be a MalformedRuleError", err)
+	}
+	count, err := a.CountPolicies(ctx, nil)
+	if !errors.As(err, &skipped) || count != 4 {
+		t.Errorf("CountPolicies: %d, %v, supposed to be 4 and a MalformedRulesError", count, err)
+	}
+	var buf bytes.Buffer
+	if err = b.ExportCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 || len(handled) != 2 {
+		t.Errorf("ExportCSV exported %d rules and handled %d, supposed to be 4 and 2", n, len(handled))
+	}
+}
+
+func TestLenientLoadInvalidRules(t *testing.T) {
+	strict, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	initPolicy(t, strict)
+	// The values decode, but cannot be loaded into the model.
+	unknown, _ := json.Marshal(CasbinRule{PType: "p9", V0: "eve", V1: "data1", V2: "read"})
+	invalid, _ := json.Marshal(CasbinRule{PType: "x", V0: "eve", V1: "data1", V2: "read"})
+	conn := strict.getConn()
+	_, err = conn.Do("RPUSH", strict.key, "null", "{}", unknown, invalid)
+	strict.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	err = strict.LoadPolicy(e.GetModel())
+	var malformed *MalformedRuleError
+	if !errors.As(err, &malformed) || malformed.Index != 5 {
+		t.Fatalf("LoadPolicy error: %v, supposed to be a MalformedRuleError at index 5", err)
+	}
+
+	for _, cached := range []bool{false, true} {
+		options := []Option{WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey("casbin_rules_invalid"), WithLenientLoad(nil)}
+		if cached {
+			options = append(options, WithCache(CacheRevision))
+		}
+		a, err := NewAdapterWithOption(options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testSkipped := func(name string, err error, first int) {
+			t.Helper()
+			var skipped *MalformedRulesError
+			if !errors.As(err, &skipped) || len(skipped.Rules) != 9-first {
+				t.Fatalf("%s (cached: %t) error: %v, supposed to be a MalformedRulesError of %d rules", name, cached, err, 9-first)
+			}
+			for i, rule := range skipped.Rules {
+				if rule.Index != first+i {
+					t.Errorf("%s (cached: %t) skipped the rule at index %d, supposed to be %d", name, cached, rule.Index, first+i)
+				}
+			}
+			testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+		}
+
+		e.ClearPolicy()
+		testSkipped("LoadPolicy", a.LoadPolicy(e.GetModel()), 5)
+		// A second load is served from the cache, if enabled.
+		e.ClearPolicy()
+		testSkipped("LoadPolicy", a.LoadPolicy(e.GetModel()), 5)
+		// The values that are not encoded rules never match a filter.
+		e.ClearPolicy()
+		testSkipped("LoadFilteredPolicy", a.LoadFilteredPolicy(e.GetModel(), &Filter{}), 7)
+		a.Close()
+	}
+}
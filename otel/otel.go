@@ -0,0 +1,103 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel traces the operations of the Redis adapter with OpenTelemetry.
+//
+//	a, err := redisadapter.NewAdapterWithOption(redisadapter.WithAddress("127.0.0.1:6379"),
+//		redisadapter.WithTracer(otel.NewTracer(otel.WithTracerProvider(tp))))
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	redisadapter "github.com/casbin/redis-adapter/v3"
+	stdotel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the adapter as the instrumentation library.
+const instrumentationName = "github.com/casbin/redis-adapter/v3"
+
+// Tracer implements redisadapter.Tracer with an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ redisadapter.Tracer = (*Tracer)(nil)
+
+// Option configures a Tracer.
+type Option func(*options)
+
+type options struct {
+	provider trace.TracerProvider
+}
+
+// WithTracerProvider sets the provider of the tracer. The global provider is
+// used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.provider = provider
+	}
+}
+
+// NewTracer creates a tracer for the adapter.
+func NewTracer(opts ...Option) *Tracer {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.provider == nil {
+		o.provider = stdotel.GetTracerProvider()
+	}
+	return &Tracer{tracer: o.provider.Tracer(instrumentationName)}
+}
+
+// Start implements redisadapter.Tracer.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, redisadapter.Span) {
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "redis")))
+	return ctx, &Span{span: span}
+}
+
+// Span implements redisadapter.Span with an OpenTelemetry span.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttribute implements redisadapter.Span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// End implements redisadapter.Span.
+func (s *Span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"testing"
+
+	redisadapter "github.com/casbin/redis-adapter/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func attributeValue(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	a, err := redisadapter.NewAdapterWithOption(redisadapter.WithNetwork("tcp"), redisadapter.WithAddress("127.0.0.1:6379"),
+		redisadapter.WithKey("casbin_rules_otel"), redisadapter.WithTracer(NewTracer(WithTracerProvider(provider))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if err = a.AddPolicies("p", "p", [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.AddPolicy("x", "p", []string{"alice", "data1", "read"}); err == nil {
+		t.Fatal("AddPolicy should fail on an unknown section")
+	}
+	tracer := provider.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	if err = a.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 5 {
+		t.Fatalf("%d spans, supposed to be 5", len(spans))
+	}
+
+	// The script span ends before the span of its operation.
+	script, addPolicies := spans[0], spans[1]
+	if script.Name() != "script add_policies" || addPolicies.Name() != "AddPolicies" {
+		t.Fatalf("spans: %q, %q", script.Name(), addPolicies.Name())
+	}
+	if script.Parent().SpanID() != addPolicies.SpanContext().SpanID() {
+		t.Error("The script span should be a child of the AddPolicies span")
+	}
+	if v, _ := attributeValue(script.Attributes(), redisadapter.AttributeScript); v.AsString() != "add_policies" {
+		t.Errorf("script attribute: %q", v.AsString())
+	}
+	if v, _ := attributeValue(addPolicies.Attributes(), redisadapter.AttributeKey); v.AsString() != "casbin_rules_otel" {
+		t.Errorf("key attribute: %q", v.AsString())
+	}
+	if v, _ := attributeValue(addPolicies.Attributes(), redisadapter.AttributePType); v.AsString() != "p" {
+		t.Errorf("ptype attribute: %q", v.AsString())
+	}
+	if _, ok := attributeValue(addPolicies.Attributes(), redisadapter.AttributeRulesWritten); !ok {
+		t.Error("AddPolicies should have the rules written attribute")
+	}
+
+	if addPolicy := spans[2]; addPolicy.Name() != "AddPolicy" || addPolicy.Status().Code != codes.Error {
+		t.Errorf("AddPolicy span: %q, %v", addPolicy.Name(), addPolicy.Status())
+	}
+
+	if ping := spans[3]; ping.Name() != "Ping" || ping.Parent().SpanID() != spans[4].SpanContext().SpanID() {
+		t.Error("The Ping span should be a child of the span in the context")
+	}
+}
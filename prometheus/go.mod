@@ -0,0 +1,25 @@
+module github.com/casbin/redis-adapter/v3/prometheus
+
+go 1.21
+
+require (
+	github.com/casbin/redis-adapter/v3 v3.0.0
+	github.com/prometheus/client_golang v1.19.1
+)
+
+require (
+	github.com/Knetic/govaluate v3.0.1-0.20171022003610-9aa49832a739+incompatible // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/casbin/casbin/v2 v2.60.0 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gomodule/redigo v1.8.9 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
+
+// The adapter is developed in the same repository.
+replace github.com/casbin/redis-adapter/v3 => ../
@@ -0,0 +1,142 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus exports the metrics of the Redis adapter to Prometheus.
+//
+//	m := prometheus.NewMetrics("casbin")
+//	a, err := redisadapter.NewAdapterWithOption(redisadapter.WithAddress("127.0.0.1:6379"), redisadapter.WithMetrics(m))
+//	stdprometheus.MustRegister(m, prometheus.NewPoolCollector("casbin", a))
+package prometheus
+
+import (
+	redisadapter "github.com/casbin/redis-adapter/v3"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const subsystem = "redis_adapter"
+
+// Metrics implements redisadapter.Metrics and collects the operation metrics.
+type Metrics struct {
+	duration *stdprometheus.HistogramVec
+	errors   *stdprometheus.CounterVec
+	rules    *stdprometheus.CounterVec
+	bytes    *stdprometheus.CounterVec
+}
+
+var _ redisadapter.Metrics = (*Metrics)(nil)
+
+// NewMetrics creates the operation metrics. They must be registered before
+// they are exported.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		duration: stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of the adapter operations.",
+			Buckets:   stdprometheus.DefBuckets,
+		}, []string{"operation"}),
+		errors: stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "operation_errors_total",
+			Help:      "Number of adapter operations that returned an error.",
+		}, []string{"operation"}),
+		rules: stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rules_total",
+			Help:      "Number of rules read from or written to Redis.",
+		}, []string{"operation", "direction"}),
+		bytes: stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "payload_bytes_total",
+			Help:      "Size of the encoded rules read from or written to Redis.",
+		}, []string{"operation", "direction"}),
+	}
+}
+
+// ObserveOperation implements redisadapter.Metrics.
+func (m *Metrics) ObserveOperation(stats redisadapter.OperationStats) {
+	m.duration.WithLabelValues(stats.Operation).Observe(stats.Duration.Seconds())
+	if stats.Err != nil {
+		m.errors.WithLabelValues(stats.Operation).Inc()
+	}
+	if stats.RulesRead > 0 || stats.BytesRead > 0 {
+		m.rules.WithLabelValues(stats.Operation, "read").Add(float64(stats.RulesRead))
+		m.bytes.WithLabelValues(stats.Operation, "read").Add(float64(stats.BytesRead))
+	}
+	if stats.RulesWritten > 0 || stats.BytesWritten > 0 {
+		m.rules.WithLabelValues(stats.Operation, "written").Add(float64(stats.RulesWritten))
+		m.bytes.WithLabelValues(stats.Operation, "written").Add(float64(stats.BytesWritten))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *stdprometheus.Desc) {
+	m.duration.Describe(ch)
+	m.errors.Describe(ch)
+	m.rules.Describe(ch)
+	m.bytes.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- stdprometheus.Metric) {
+	m.duration.Collect(ch)
+	m.errors.Collect(ch)
+	m.rules.Collect(ch)
+	m.bytes.Collect(ch)
+}
+
+// PoolCollector collects the connection pool statistics of an adapter when
+// it is scraped.
+type PoolCollector struct {
+	adapter      *redisadapter.Adapter
+	active       *stdprometheus.Desc
+	idle         *stdprometheus.Desc
+	waitCount    *stdprometheus.Desc
+	waitDuration *stdprometheus.Desc
+}
+
+// NewPoolCollector creates a collector for the connection pool of a.
+func NewPoolCollector(namespace string, a *redisadapter.Adapter) *PoolCollector {
+	name := func(name string) string {
+		return stdprometheus.BuildFQName(namespace, subsystem, name)
+	}
+	return &PoolCollector{
+		adapter:      a,
+		active:       stdprometheus.NewDesc(name("pool_active_connections"), "Number of open connections, including the idle ones.", nil, nil),
+		idle:         stdprometheus.NewDesc(name("pool_idle_connections"), "Number of idle connections.", nil, nil),
+		waitCount:    stdprometheus.NewDesc(name("pool_wait_total"), "Number of times a connection was waited for.", nil, nil),
+		waitDuration: stdprometheus.NewDesc(name("pool_wait_seconds_total"), "Total time spent waiting for a connection.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PoolCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	ch <- c.active
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+// Collect implements prometheus.Collector.
+func (c *PoolCollector) Collect(ch chan<- stdprometheus.Metric) {
+	stats := c.adapter.PoolStats()
+	ch <- stdprometheus.MustNewConstMetric(c.active, stdprometheus.GaugeValue, float64(stats.ActiveCount))
+	ch <- stdprometheus.MustNewConstMetric(c.idle, stdprometheus.GaugeValue, float64(stats.IdleCount))
+	ch <- stdprometheus.MustNewConstMetric(c.waitCount, stdprometheus.CounterValue, float64(stats.WaitCount))
+	ch <- stdprometheus.MustNewConstMetric(c.waitDuration, stdprometheus.CounterValue, stats.WaitDuration.Seconds())
+}
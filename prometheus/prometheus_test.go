@@ -0,0 +1,61 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	redisadapter "github.com/casbin/redis-adapter/v3"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics("casbin")
+	a, err := redisadapter.NewAdapterWithOption(redisadapter.WithNetwork("tcp"), redisadapter.WithAddress("127.0.0.1:6379"),
+		redisadapter.WithKey("casbin_rules_prometheus"), redisadapter.WithMetrics(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	registry := stdprometheus.NewRegistry()
+	registry.MustRegister(m, NewPoolCollector("casbin", a))
+
+	// Start from an empty policy.
+	if err = a.ImportCSV(strings.NewReader(""), redisadapter.ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.AddPolicies("p", "p", [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.AddPolicy("x", "p", []string{"alice", "data1", "read"}); err == nil {
+		t.Fatal("AddPolicy should fail on an unknown section")
+	}
+
+	if v := testutil.ToFloat64(m.rules.WithLabelValues("AddPolicies", "written")); v != 2 {
+		t.Errorf("rules written by AddPolicies: %v, supposed to be 2", v)
+	}
+	if v := testutil.ToFloat64(m.errors.WithLabelValues("AddPolicy")); v != 1 {
+		t.Errorf("errors of AddPolicy: %v, supposed to be 1", v)
+	}
+	if n := testutil.CollectAndCount(m, "casbin_redis_adapter_operation_duration_seconds"); n != 3 {
+		t.Errorf("duration metrics: %d, supposed to be 3", n)
+	}
+	if n := testutil.CollectAndCount(registry, "casbin_redis_adapter_pool_active_connections"); n != 1 {
+		t.Errorf("pool metrics: %d, supposed to be 1", n)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// scanBatchSize is the number of rules read per round trip by the methods
+// that scan the list.
+const scanBatchSize = 1000
+
+// scanRules calls fn with the stored rules matching the filter, from index
+// start on, until fn returns false. A nil filter matches every rule. The rules
+// are read in pages of pageSize rules, so they are not a consistent snapshot
+// of the list, unless pageSize is 0 and they are read at once. Malformed rules
+// are handled as by LoadPolicy.
+func (a *Adapter) scanRules(ctx context.Context, op *operation, conn redis.Conn, filter *Filter, start int, pageSize int,
+	fn func(index int, line CasbinRule) (bool, error)) error {
+	var skipped []*MalformedRuleError
+	var m *ruleMatcher
+	if filter != nil {
+		m = newRuleMatcher(filter)
+	}
+
+	for ; ; start += pageSize {
+		stop := -1
+		if pageSize > 0 {
+			stop = start + pageSize - 1
+		}
+		values, err := redis.Values(redis.DoContext(conn, ctx, "LRANGE", a.key, start, stop))
+		if err != nil {
+			return err
+		}
+
+		for i, value := range values {
+			text, err := valueToBytes(value)
+			if err != nil {
+				if err = op.skipMalformedRule(&skipped, start+i, value, err); err != nil {
+					return err
+				}
+				continue
+			}
+			op.addRead(1, len(text))
+			if m != nil && !m.matchText(text) {
+				continue
+			}
+
+			var line CasbinRule
+			if err = json.Unmarshal(text, &line); err != nil {
+				if err = op.skipMalformedRule(&skipped, start+i, value, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if m != nil && !m.match(&line) {
+				continue
+			}
+			more, err := fn(start+i, line)
+			if err != nil {
+				return err
+			}
+			if !more {
+				return skippedRulesError(skipped)
+			}
+		}
+
+		if pageSize == 0 || len(values) < pageSize {
+			return skippedRulesError(skipped)
+		}
+	}
+}
+
+// GetPolicies returns the stored rules matching the filter, without loading
+// them into a model. Each rule starts with its ptype. A nil filter matches
+// every rule. The rules are read in pages, so rules removed concurrently may
+// make it skip other rules. If malformed rules are skipped because of
+// WithLenientLoad without a handler, the other rules are returned with a
+// *MalformedRulesError.
+func (a *Adapter) GetPolicies(ctx context.Context, filter *Filter) (_ [][]string, err error) {
+	op := a.startOperationContext(ctx, "GetPolicies")
+	defer func() { op.end(err) }()
+
+	rules, _, err := a.getPolicies(ctx, op, filter, 0, 0)
+	return rules, err
+}
+
+// GetPoliciesPage returns at most limit stored rules matching the filter,
+// starting at cursor, and the cursor of the next page. The first page starts
+// at cursor 0, and a returned cursor of 0 means that there are no more pages;
+// the last page may be empty. A limit of 0 or less returns all remaining
+// rules. Cursors are positions in the list, so pages may skip or repeat rules
+// if rules are removed concurrently.
+func (a *Adapter) GetPoliciesPage(ctx context.Context, filter *Filter, cursor int, limit int) (_ [][]string, next int, err error) {
+	op := a.startOperationContext(ctx, "GetPoliciesPage")
+	defer func() { op.end(err) }()
+
+	return a.getPolicies(ctx, op, filter, cursor, limit)
+}
+
+func (a *Adapter) getPolicies(ctx context.Context, op *operation, filter *Filter, cursor int, limit int) ([][]string, int, error) {
+	if cursor < 0 {
+		cursor = 0
+	}
+
+	conn, err := a.getConnContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer a.release(conn)
+
+	rules := [][]string{}
+	next := 0
+	err = a.scanRules(ctx, op, conn, filter, cursor, scanBatchSize, func(index int, line CasbinRule) (bool, error) {
+		rules = append(rules, line.toStringPolicy())
+		if limit > 0 && len(rules) == limit {
+			next = index + 1
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil && !errors.As(err, new(*MalformedRulesError)) {
+		return nil, 0, err
+	}
+	return rules, next, err
+}
+
+// CountPolicies returns the number of stored rules matching the filter. A nil
+// filter counts every rule. Malformed rules are not counted, so the count
+// matches the rules returned by GetPolicies.
+func (a *Adapter) CountPolicies(ctx context.Context, filter *Filter) (_ int, err error) {
+	op := a.startOperationContext(ctx, "CountPolicies")
+	defer func() { op.end(err) }()
+
+	conn, err := a.getConnContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer a.release(conn)
+
+	count := 0
+	err = a.scanRules(ctx, op, conn, filter, 0, scanBatchSize, func(int, CasbinRule) (bool, error) {
+		count++
+		return true, nil
+	})
+	if err != nil && !errors.As(err, new(*MalformedRulesError)) {
+		return 0, err
+	}
+	return count, err
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestGetPolicies(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	initPolicy(t, a)
+	ctx := context.Background()
+
+	rules, err := a.GetPolicies(ctx, &Filter{V0: []string{"alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"p", "alice", "data1", "read"}, {"g", "alice", "data2_admin"}}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("GetPolicies: %v, supposed to be %v", rules, expected)
+	}
+
+	count, err := a.CountPolicies(ctx, &Filter{PType: []string{"p"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("CountPolicies: %d, supposed to be 4", count)
+	}
+	if count, err = a.CountPolicies(ctx, nil); err != nil || count != 5 {
+		t.Errorf("CountPolicies without filter: %d, %v, supposed to be 5", count, err)
+	}
+
+	// Page through more rules than are read per round trip.
+	var many [][]string
+	for i := 0; i < scanBatchSize+10; i++ {
+		many = append(many, []string{fmt.Sprintf("user%d", i), "data3", "read"})
+	}
+	if err = a.AddPolicies("p", "p", many); err != nil {
+		t.Fatal(err)
+	}
+	filter := &Filter{V1: []string{"data3"}}
+	var paged [][]string
+	cursor, pages := 0, 0
+	for {
+		var page [][]string
+		page, cursor, err = a.GetPoliciesPage(ctx, filter, cursor, 300)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > 300 {
+			t.Fatalf("Page of %d rules, supposed to be at most 300", len(page))
+		}
+		paged = append(paged, page...)
+		pages++
+		if cursor == 0 {
+			break
+		}
+	}
+	if len(paged) != len(many) || pages != 4 {
+		t.Fatalf("Paged %d rules in %d pages, supposed to be %d in 4", len(paged), pages, len(many))
+	}
+	for i, rule := range paged {
+		if !reflect.DeepEqual(rule[1:], many[i]) {
+			t.Fatalf("Rule %d: %v, supposed to be %v", i, rule, many[i])
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = a.GetPolicies(canceled, filter); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetPolicies with a canceled context: %v", err)
+	}
+}
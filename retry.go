@@ -0,0 +1,101 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	defaultMaxRetries      = 3
+	defaultMinRetryBackoff = 8 * time.Millisecond
+	defaultMaxRetryBackoff = 512 * time.Millisecond
+
+	// idleCheckPeriod is how long a pooled connection may stay idle before it
+	// is checked with PING when it is borrowed again.
+	idleCheckPeriod = time.Minute
+)
+
+// WithRetry configures how often the idempotent operations (LoadPolicy,
+// LoadFilteredPolicy and SavePolicy) are retried after a network error. The
+// backoff between the attempts doubles from minBackoff up to maxBackoff, with
+// a random jitter of up to half of it. A maxRetries of 0 disables retries.
+func WithRetry(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(a *Adapter) {
+		a.maxRetries = maxRetries
+		a.minRetryBackoff = minBackoff
+		a.maxRetryBackoff = maxBackoff
+	}
+}
+
+// retry runs op until it succeeds, fails with an error that is not retryable
+// or the retries are exhausted. op must get its own connection, so that a
+// broken one is discarded by the pool before the next attempt.
+func (a *Adapter) retry(op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= a.maxRetries || !isRetryable(err) {
+			return err
+		}
+		time.Sleep(a.retryBackoff(attempt))
+	}
+}
+
+func (a *Adapter) retryBackoff(attempt int) time.Duration {
+	d := a.minRetryBackoff << uint(attempt)
+	if d > a.maxRetryBackoff || d <= 0 {
+		d = a.maxRetryBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// isRetryable reports whether err is a network error, or a reply of a server
+// that is loading its data set or was demoted by a failover. Other errors
+// replied by the server are not retryable.
+func isRetryable(err error) bool {
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING ", "READONLY ", "MASTERDOWN ", "TRYAGAIN "} {
+			if strings.HasPrefix(string(redisErr), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, redis.ErrPoolExhausted) || errors.As(err, &netErr)
+}
+
+// testOnBorrow checks the connections that were idle for a while, so that a
+// connection dropped by the server is replaced instead of failing a command.
+func testOnBorrow(conn redis.Conn, t time.Time) error {
+	if time.Since(t) < idleCheckPeriod {
+		return nil
+	}
+	_, err := conn.Do("PING")
+	return err
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		err       error
+		retryable bool
+	}{
+		{io.EOF, true},
+		{io.ErrUnexpectedEOF, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("wrapped: %w", io.EOF), true},
+		{redis.ErrPoolExhausted, true},
+		{redis.Error("LOADING Redis is loading the dataset in memory"), true},
+		{redis.Error("READONLY You can't write against a read only replica."), true},
+		{redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{redis.Error("NOSCRIPT No matching script."), false},
+		{errors.New("the type is wrong"), false},
+	}
+
+	for _, tc := range testCases {
+		if isRetryable(tc.err) != tc.retryable {
+			t.Errorf("isRetryable(%v): %t, supposed to be %t", tc.err, !tc.retryable, tc.retryable)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	a := newDefaultAdapter()
+	for attempt := 0; attempt < 20; attempt++ {
+		d := a.retryBackoff(attempt)
+		if d < defaultMinRetryBackoff/2 || d > defaultMaxRetryBackoff {
+			t.Errorf("retryBackoff(%d): %v, out of range", attempt, d)
+		}
+	}
+}
+
+// dropConns dials real connections and can close them behind the back of
+// the pool, the same way a restart of the server does.
+type dropConns struct {
+	mu    sync.Mutex
+	conns []net.Conn
+	fails int
+}
+
+func (d *dropConns) dial() (redis.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fails > 0 {
+		d.fails--
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	c, err := net.Dial("tcp", "127.0.0.1:6379")
+	if err != nil {
+		return nil, err
+	}
+	d.conns = append(d.conns, c)
+	return redis.NewConn(c, time.Second, time.Second), nil
+}
+
+func (d *dropConns) drop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, c := range d.conns {
+		c.Close()
+	}
+	d.conns = nil
+}
+
+func TestRetry(t *testing.T) {
+	d := &dropConns{fails: 2}
+	a, err := NewAdapterWithPoolAndOptions(&redis.Pool{Dial: d.dial, MaxIdle: 1}, WithKey("casbin_rules_retry"), WithRetry(3, time.Millisecond, 4*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	initPolicy(t, a)
+
+	// The idle connection of the pool is broken, LoadPolicy reconnects.
+	d.drop()
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+
+	d.drop()
+	if err = e.SavePolicy(); err != nil {
+		t.Fatal(err)
+	}
+	d.drop()
+	if err = e.LoadFilteredPolicy(Filter{V0: []string{"alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}})
+
+	// Without retries the broken connection surfaces.
+	a.maxRetries = 0
+	d.drop()
+	if err = a.LoadPolicy(e.GetModel()); err == nil {
+		t.Error("LoadPolicy should fail on a broken connection without retries")
+	}
+
+	// The error surfaces when the server stays unreachable.
+	a.maxRetries = 3
+	d.fails = 4
+	d.drop()
+	if err = a.LoadPolicy(e.GetModel()); err == nil {
+		t.Error("LoadPolicy should fail when the retries are exhausted")
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gomodule/redigo/redis"
+)
+
+// saveAttempts is the number of times SaveFilteredPolicy reads the stored
+// rules again when they are modified concurrently.
+const saveAttempts = 3
+
+// ErrConcurrentSave is returned by SaveFilteredPolicy when the stored rules
+// keep being modified while it runs.
+var ErrConcurrentSave = errors.New("rules were modified during save")
+
+// errWatchedKeyModified is returned by a transaction that was aborted
+// because the watched key was modified.
+var errWatchedKeyModified = errors.New("watched key was modified")
+
+// PolicyDiff summarizes the changes of the stored rules made by
+// SavePolicyDiff.
+type PolicyDiff struct {
+	Added int
+	// Removed includes the duplicates of kept rules.
+	Removed   int
+	Unchanged int
+	// Rewritten reports whether all rules were written again because the
+	// stored order of the rules of a ptype differed from the model.
+	Rewritten bool
+}
+
+// SavePolicyDiff saves the policy of the model like SavePolicy and returns
+// the changes of the stored rules. The rules that are kept are not written
+// again, and the changes are applied atomically.
+func (a *Adapter) SavePolicyDiff(model model.Model) (_ *PolicyDiff, err error) {
+	op := a.startOperation("SavePolicyDiff")
+	defer func() { op.end(err) }()
+	a.beginWrite(op)
+
+	return a.savePolicy(op, model)
+}
+
+func (a *Adapter) savePolicy(op *operation, model model.Model) (*PolicyDiff, error) {
+	var args redis.Args
+	var texts [][]byte
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range model[sec] {
+			for _, rule := range ast.Policy {
+				line, err := savePolicyLine(sec, ptype, rule)
+				if err != nil {
+					return nil, err
+				}
+				text, err := json.Marshal(line)
+				if err != nil {
+					return nil, err
+				}
+				args = args.Add(ptype, text)
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	// The script leads to the same rules when it is retried after a lost
+	// reply.
+	var reply []int
+	err := a.retry(func() error {
+		conn := a.getConn()
+		defer a.release(conn)
+
+		var err error
+		reply, err = redis.Ints(a.evalBulkScript(op, conn, savePolicyScript, args))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &PolicyDiff{Added: reply[0], Removed: reply[1], Unchanged: reply[2], Rewritten: reply[3] == 1}
+	if diff.Rewritten {
+		op.addWritten(len(texts), payloadSize(texts))
+	} else {
+		op.addWritten(diff.Added, 0)
+	}
+	op.addWritten(diff.Removed, 0)
+	return diff, nil
+}
+
+// encodeModel returns the encoded rules of the model that match m, or all
+// rules if m is nil.
+func encodeModel(model model.Model, m *ruleMatcher) ([][]byte, error) {
+	var texts [][]byte
+	for _, sec := range []string{"p", "g"} {
+		for ptype, ast := range model[sec] {
+			for _, rule := range ast.Policy {
+				line, err := savePolicyLine(sec, ptype, rule)
+				if err != nil {
+					return nil, err
+				}
+				if m != nil && !m.match(&line) {
+					continue
+				}
+				text, err := json.Marshal(line)
+				if err != nil {
+					return nil, err
+				}
+				texts = append(texts, text)
+			}
+		}
+	}
+	return texts, nil
+}
+
+// SaveFilteredPolicy replaces the stored rules that match the filter with
+// the rules of the model that match it, leaving all other stored rules
+// untouched, e.g. to save the policy of a tenant loaded with
+// LoadFilteredPolicy. Rules of the model that do not match the filter are
+// not saved. The rules are replaced atomically.
+func (a *Adapter) SaveFilteredPolicy(model model.Model, filter interface{}) (err error) {
+	op := a.startOperation("SaveFilteredPolicy")
+	defer func() { op.end(err) }()
+	a.beginWrite(op)
+
+	f, err := toFilter(filter)
+	if err != nil {
+		return err
+	}
+	var m *ruleMatcher
+	if f != nil {
+		m = newRuleMatcher(f)
+	}
+	texts, err := encodeModel(model, m)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < saveAttempts; attempt++ {
+		err = a.retry(func() error {
+			conn := a.getConn()
+			defer a.release(conn)
+
+			return a.saveFiltered(op, conn, m, texts)
+		})
+		if err != errWatchedKeyModified {
+			return err
+		}
+	}
+	return ErrConcurrentSave
+}
+
+// saveFiltered reads the stored rules matching m while watching the key and
+// replaces those that are not in texts by the missing texts in a
+// transaction.
+func (a *Adapter) saveFiltered(op *operation, conn redis.Conn, m *ruleMatcher, texts [][]byte) error {
+	if _, err := conn.Do("WATCH", a.key); err != nil {
+		return err
+	}
+	values, err := redis.Values(conn.Do("LRANGE", a.key, 0, -1))
+	if err != nil {
+		return err
+	}
+
+	inModel := make(map[string]bool, len(texts))
+	for _, text := range texts {
+		inModel[string(text)] = true
+	}
+	stored := make(map[string]bool)
+	var removed [][]byte
+	for _, value := range values {
+		text, err := valueToBytes(value)
+		if err != nil {
+			// Rules that cannot be decoded do not match any filter.
+			continue
+		}
+		op.addRead(1, len(text))
+		if m != nil {
+			var line CasbinRule
+			if !m.matchText(text) || json.Unmarshal(text, &line) != nil || !m.match(&line) {
+				continue
+			}
+		}
+
+		// LREM removes all duplicates of a rule at once.
+		if stored[string(text)] {
+			continue
+		}
+		stored[string(text)] = true
+		if !inModel[string(text)] {
+			removed = append(removed, text)
+		}
+	}
+
+	var added [][]byte
+	for _, text := range texts {
+		if !stored[string(text)] {
+			added = append(added, text)
+			stored[string(text)] = true
+		}
+	}
+	if len(removed) == 0 && len(added) == 0 {
+		_, err = conn.Do("UNWATCH")
+		return err
+	}
+
+	// The commands are flushed in batches, the transaction applies them at once.
+	if err = conn.Send("MULTI"); err != nil {
+		return err
+	}
+	for i, text := range removed {
+		if err = conn.Send("LREM", a.key, 0, text); err != nil {
+			return err
+		}
+		if (i+1)%a.batchSize == 0 {
+			if err = conn.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err = a.sendBatches(conn, a.key, redis.Args{}.AddFlat(added)); err != nil {
+		return err
+	}
+	if err = a.sendRevision(conn); err != nil {
+		return err
+	}
+	reply, err := conn.Do("EXEC")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return errWatchedKeyModified
+	}
+	op.addWritten(len(removed), payloadSize(removed))
+	op.addWritten(len(added), payloadSize(added))
+	return nil
+}
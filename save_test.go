@@ -0,0 +1,221 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestSaveFilteredPolicy(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_save_filtered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err = a.ImportCSV(strings.NewReader(`p, admin, domain1, data1, read
+p, admin, domain2, data2, read
+p, admin, domain1, data1, write
+g, alice, admin, domain1
+g, bob, admin, domain2
+`), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := casbin.NewEnforcer("examples/rbac_with_domains_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.EnableAutoSave(false)
+	filter := domainFilter("domain1")
+	if err = e.LoadFilteredPolicy(filter); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.RemovePolicy("admin", "domain1", "data1", "write"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.AddPolicy("admin", "domain1", "data3", "read"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.AddGroupingPolicy("carol", "admin", "domain1"); err != nil {
+		t.Fatal(err)
+	}
+	// Rules outside of the filter are not saved.
+	if _, err = e.AddPolicy("admin", "domain2", "data4", "read"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = a.SaveFilteredPolicy(e.GetModel(), filter); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := a.GetPolicies(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"p", "admin", "domain1", "data1", "read"},
+		{"p", "admin", "domain2", "data2", "read"},
+		{"g", "alice", "admin", "domain1"},
+		{"g", "bob", "admin", "domain2"},
+		{"p", "admin", "domain1", "data3", "read"},
+		{"g", "carol", "admin", "domain1"},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Stored rules: %v, supposed to be %v", rules, expected)
+	}
+
+	// Saving again does not change anything.
+	if err = a.SaveFilteredPolicy(e.GetModel(), filter); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, len(expected))
+}
+
+func TestSaveFilteredPolicyEscaped(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_save_escaped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	// JSON escapes &, < and > in the stored rules.
+	if err = a.ImportCSV(strings.NewReader(`p, admin, R&D, data1, read
+p, admin, R&D, data2, read
+p, admin, <ops>, data1, read
+g, alice, admin, R&D
+`), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := casbin.NewEnforcer("examples/rbac_with_domains_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.EnableAutoSave(false)
+	filter := &Filter{PType: []string{"p"}, V1: []string{"R&D"}}
+	if err = e.LoadFilteredPolicy(filter); err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"admin", "R&D", "data1", "read"}, {"admin", "R&D", "data2", "read"}})
+
+	if _, err = e.RemovePolicy("admin", "R&D", "data2", "read"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = a.SaveFilteredPolicy(e.GetModel(), filter); err != nil {
+			t.Fatal(err)
+		}
+		testStoredCount(t, a, 3)
+	}
+
+	if err = a.RemoveFilteredPolicy("p", "p", 1, "<ops>"); err != nil {
+		t.Fatal(err)
+	}
+	testStoredCount(t, a, 2)
+}
+
+func TestSavePolicyDiff(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_save_diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", "examples/rbac_policy.csv")
+
+	encode := func(rules ...[]string) redis.Args {
+		args := redis.Args{}.Add(a.key)
+		for _, rule := range rules {
+			line, err := savePolicyLine(rule[0][:1], rule[0], rule[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := json.Marshal(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args = args.Add(text)
+		}
+		return args
+	}
+	store := func(args redis.Args) {
+		t.Helper()
+		conn := a.getConn()
+		defer a.release(conn)
+		if _, err := conn.Do("DEL", a.key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Do("RPUSH", args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testDiff := func(expected PolicyDiff, rules [][]string) {
+		t.Helper()
+		diff, err := a.SavePolicyDiff(e.GetModel())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *diff != expected {
+			t.Errorf("SavePolicyDiff: %+v, supposed to be %+v", *diff, expected)
+		}
+		stored, err := a.GetPolicies(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(stored, rules) {
+			t.Errorf("Stored rules: %v, supposed to be %v", stored, rules)
+		}
+	}
+
+	// Kept rules keep their position, the others are removed or appended.
+	store(encode(
+		[]string{"p", "alice", "data1", "read"},
+		[]string{"g", "alice", "data2_admin"},
+		[]string{"p", "bob", "data2", "write"},
+		[]string{"p", "carol", "data3", "read"},
+		[]string{"p", "alice", "data1", "read"},
+	).Add(deletedSentinel))
+	testDiff(PolicyDiff{Added: 2, Removed: 3, Unchanged: 3}, [][]string{
+		{"p", "alice", "data1", "read"},
+		{"g", "alice", "data2_admin"},
+		{"p", "bob", "data2", "write"},
+		{"p", "data2_admin", "data2", "read"},
+		{"p", "data2_admin", "data2", "write"},
+	})
+	testDiff(PolicyDiff{Unchanged: 5}, [][]string{
+		{"p", "alice", "data1", "read"},
+		{"g", "alice", "data2_admin"},
+		{"p", "bob", "data2", "write"},
+		{"p", "data2_admin", "data2", "read"},
+		{"p", "data2_admin", "data2", "write"},
+	})
+
+	// A different order of the rules of a ptype is restored.
+	store(encode(
+		[]string{"p", "bob", "data2", "write"},
+		[]string{"p", "alice", "data1", "read"},
+	))
+	testDiff(PolicyDiff{Added: 3, Unchanged: 2, Rewritten: true}, [][]string{
+		{"p", "alice", "data1", "read"},
+		{"p", "bob", "data2", "write"},
+		{"p", "data2_admin", "data2", "read"},
+		{"p", "data2_admin", "data2", "write"},
+		{"g", "alice", "data2_admin"},
+	})
+}
@@ -0,0 +1,447 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// luaScript is a Lua script that is loaded once with SCRIPT LOAD and then
+// invoked with EVALSHA, or registered as a function of the casbin library
+// and invoked with FCALL.
+type luaScript struct {
+	name     string
+	keyCount int
+	src      string
+	script   *redis.Script
+}
+
+// bumpRevisionSrc defines bumpRevision, which increments the revision counter
+// passed as a key unless the adapter does not maintain it and passes "". The
+// scripts that write the rules take the counter as their last key and call it
+// only when they changed the list.
+const bumpRevisionSrc = `
+	local function bumpRevision(key)
+		if key ~= '' then
+			redis.call('incr', key)
+		end
+	end
+`
+
+func newLuaScript(name string, keyCount int, src string) *luaScript {
+	if strings.Contains(src, "bumpRevision(") {
+		src = bumpRevisionSrc + src
+	}
+	return &luaScript{
+		name:     name,
+		keyCount: keyCount,
+		src:      src,
+		script:   redis.NewScript(keyCount, src),
+	}
+}
+
+// addPoliciesScript pushes the rules in ARGV, or in the staging list KEYS[2]
+// if ARGV is empty, that are not stored yet and returns 1 for each inserted
+// rule and 0 for each skipped one. It reads the whole list, so its cost grows
+// with the number of stored rules.
+var addPoliciesScript = newLuaScript("add_policies", 3, `
+	local key = KEYS[1]
+	local rules = ARGV
+	if #rules == 0 then
+		rules = redis.call('lrange', KEYS[2], 0, -1)
+		redis.call('del', KEYS[2])
+	end
+
+	local exists = {}
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1,#r do
+		exists[r[i]] = true
+	end
+
+	local added = {}
+	local inserted = 0
+	for i=1,#rules do
+		if exists[rules[i]] then
+			added[i] = 0
+		else
+			redis.call('rpush', key, rules[i])
+			exists[rules[i]] = true
+			added[i] = 1
+			inserted = inserted + 1
+		end
+	end
+	if inserted > 0 then
+		bumpRevision(KEYS[3])
+	end
+	return added
+`)
+
+// addPolicyScript pushes the rule in ARGV[1] unless it is stored, and returns
+// 1 if it was inserted. LPOS scans the list without copying it, so adding a
+// single rule does not need the table of addPoliciesScript.
+var addPolicyScript = newLuaScript("add_policy", 2, `
+	if redis.call('lpos', KEYS[1], ARGV[1]) then
+		return 0
+	end
+	redis.call('rpush', KEYS[1], ARGV[1])
+	bumpRevision(KEYS[2])
+	return 1
+`)
+
+// deduplicateScript keeps the first occurrence of each rule and returns the
+// number of removed rules.
+var deduplicateScript = newLuaScript("deduplicate", 2, `
+	local key = KEYS[1]
+
+	local seen = {}
+	local removed = 0
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1, #r do
+		if seen[r[i]] then
+			redis.call('lset', key, i-1, '__CASBIN_DELETED__')
+			removed = removed + 1
+		else
+			seen[r[i]] = true
+		end
+	end
+	redis.call('lrem', key, 0, '__CASBIN_DELETED__')
+	if removed > 0 then
+		bumpRevision(KEYS[2])
+	end
+	return removed
+`)
+
+// removeFilteredPolicyScript removes the rules matching the Lua pattern ARGV[1].
+var removeFilteredPolicyScript = newLuaScript("remove_filtered_policy", 2, `
+	local key = KEYS[1]
+	local pattern = ARGV[1]
+
+	local removed = 0
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1, #r do
+		if string.find(r[i], pattern) then
+			redis.call('lset', key, i-1, '__CASBIN_DELETED__')
+			removed = removed + 1
+		end
+	end
+	if removed > 0 then
+		redis.call('lrem', key, 0, '__CASBIN_DELETED__')
+		bumpRevision(KEYS[2])
+	end
+	return
+`)
+
+// updatePolicyScript replaces the first occurrence of ARGV[1] with ARGV[2]
+// and returns 1 if it was found, 0 otherwise.
+var updatePolicyScript = newLuaScript("update_policy", 2, `
+	local key = KEYS[1]
+	local old = ARGV[1]
+	local newRule = ARGV[2]
+
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1,#r do
+		if r[i] == old then
+			redis.call('lset', key, i-1, newRule)
+			bumpRevision(KEYS[2])
+			return 1
+		end
+	end
+	return 0
+`)
+
+// updatePoliciesScript replaces each rule in the first half of ARGV with the
+// rule at the same position in the second half and returns the number of
+// replaced rules.
+var updatePoliciesScript = newLuaScript("update_policies", 2, `
+	local key = KEYS[1]
+	local len = #ARGV/2
+
+	local map = {}
+	for i = 1, len, 1 do
+		map[ARGV[i]] = ARGV[i + len] -- map[oldRule] = newRule
+	end
+
+	local updated = 0
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1,#r do
+		if map[r[i]] ~= nil then
+			redis.call('lset', key, i-1, map[r[i]])
+			updated = updated + 1
+		end
+	end
+	if updated > 0 then
+		bumpRevision(KEYS[2])
+	end
+
+	return updated
+`)
+
+// updateFilteredPoliciesScript removes the rules matching the Lua pattern
+// ARGV[1], pushes the rules in ARGV[2:] that are not stored yet and returns
+// the removed rules.
+var updateFilteredPoliciesScript = newLuaScript("update_filtered_policies", 2, `
+	local key = KEYS[1]
+	local pattern = ARGV[1]
+
+	local ret = {}
+	local exists = {}
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1, #r do
+		if string.find(r[i], pattern) then
+			table.insert(ret, r[i])
+			redis.call('lset', key, i-1, '__CASBIN_DELETED__')
+		else
+			exists[r[i]] = true
+		end
+	end
+	redis.call('lrem', key, 0, '__CASBIN_DELETED__')
+
+	local changed = #ret > 0
+	for i=2, #ARGV do
+		if not exists[ARGV[i]] then
+			redis.call('rpush', key, ARGV[i])
+			exists[ARGV[i]] = true
+			changed = true
+		end
+	end
+	if changed then
+		bumpRevision(KEYS[2])
+	end
+
+	return ret
+`)
+
+// loadFilteredPolicyScript returns the rules whose fields are contained in
+// the lists of the JSON encoded Filter in ARGV[1] and of its P or G filter,
+// depending on the section of the rule. It is only invoked as a function,
+// the EVAL mode filters the rules on the client.
+var loadFilteredPolicyScript = newLuaScript("load_filtered_policy", 1, `
+	local key = KEYS[1]
+	local filter = cjson.decode(ARGV[1])
+
+	local function compile(f)
+		local sets = {}
+		if type(f) ~= 'table' then
+			return sets
+		end
+		for _, field in ipairs({'PType', 'V0', 'V1', 'V2', 'V3', 'V4', 'V5'}) do
+			local values = f[field]
+			if type(values) == 'table' and #values > 0 then
+				local set = {}
+				for _, v in ipairs(values) do
+					set[v] = true
+				end
+				sets[field] = set
+			end
+		end
+		return sets
+	end
+
+	local function match(sets, rule)
+		for field, set in pairs(sets) do
+			if not set[rule[field]] then
+				return false
+			end
+		end
+		return true
+	end
+
+	local sets = compile(filter)
+	local sections = {p = compile(filter['P']), g = compile(filter['G'])}
+
+	local ret = {}
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1, #r do
+		local ok, rule = pcall(cjson.decode, r[i])
+		if ok and type(rule) == 'table' and type(rule['PType']) == 'string' then
+			local section = sections[string.sub(rule['PType'], 1, 1)]
+			if match(sets, rule) and (section == nil or match(section, rule)) then
+				table.insert(ret, r[i])
+			end
+		end
+	end
+	return ret
+`)
+
+// repairScript removes the rules at the indexes in ARGV[3], ARGV[5], ... if
+// the list still has ARGV[2] rules and holds the values ARGV[4], ARGV[6], ...
+// at these indexes, using the unique value ARGV[1] as the placeholder of the
+// removed rules. It returns 1 if the rules were removed, 0 otherwise.
+var repairScript = newLuaScript("repair", 2, `
+	local key = KEYS[1]
+	local marker = ARGV[1]
+
+	local r = redis.call('lrange', key, 0, -1)
+	if #r ~= tonumber(ARGV[2]) then
+		return 0
+	end
+	for i=3, #ARGV, 2 do
+		if r[tonumber(ARGV[i]) + 1] ~= ARGV[i+1] then
+			return 0
+		end
+	end
+
+	for i=3, #ARGV, 2 do
+		redis.call('lset', key, ARGV[i], marker)
+	end
+	redis.call('lrem', key, 0, marker)
+	bumpRevision(KEYS[2])
+	return 1
+`)
+
+// savePolicyScript makes the list hold the rules in ARGV, or in the staging
+// list KEYS[2] if ARGV is empty, given as pairs of ptype and rule. Stored
+// rules that are kept keep their position, the others are removed and the
+// missing rules are appended. If that would change the order of the rules of
+// a ptype, the list is rewritten instead. It returns the numbers of added,
+// removed and unchanged rules and 1 if the list was rewritten, 0 otherwise.
+var savePolicyScript = newLuaScript("save_policy", 3, `
+	local key = KEYS[1]
+	local args = ARGV
+	if #args == 0 then
+		args = redis.call('lrange', KEYS[2], 0, -1)
+		redis.call('del', KEYS[2])
+	end
+
+	local wanted = {}
+	local order = {}
+	local ranks = {}
+	for i=1, #args, 2 do
+		local ptype, rule = args[i], args[i+1]
+		if wanted[rule] == nil then
+			local rank = ranks[ptype] or 0
+			wanted[rule] = {ptype = ptype, rank = rank}
+			ranks[ptype] = rank + 1
+			table.insert(order, rule)
+		end
+	end
+
+	local kept = {}
+	local counts = {}
+	local unchanged = 0
+	local ordered = true
+	local removed = {}
+	local r = redis.call('lrange', key, 0, -1)
+	for i=1, #r do
+		local w = wanted[r[i]]
+		if w ~= nil and not kept[r[i]] then
+			kept[r[i]] = true
+			unchanged = unchanged + 1
+			local count = counts[w.ptype] or 0
+			if w.rank ~= count then
+				ordered = false
+			end
+			counts[w.ptype] = count + 1
+		else
+			table.insert(removed, i-1)
+		end
+	end
+
+	local added = {}
+	for _, rule in ipairs(order) do
+		if not kept[rule] then
+			table.insert(added, rule)
+		end
+	end
+
+	local push = function(rules)
+		for i=1, #rules, 1000 do
+			redis.call('rpush', key, unpack(rules, i, math.min(i+999, #rules)))
+		end
+	end
+
+	if ordered then
+		for _, i in ipairs(removed) do
+			redis.call('lset', key, i, '__CASBIN_DELETED__')
+		end
+		if #removed > 0 then
+			redis.call('lrem', key, 0, '__CASBIN_DELETED__')
+		end
+		push(added)
+		if #added > 0 or #removed > 0 then
+			bumpRevision(KEYS[3])
+		end
+		return {#added, #removed, unchanged, 0}
+	end
+
+	redis.call('del', key)
+	push(order)
+	bumpRevision(KEYS[3])
+	return {#added, #removed, unchanged, 1}
+`)
+
+var luaScripts = []*luaScript{
+	addPoliciesScript,
+	addPolicyScript,
+	deduplicateScript,
+	removeFilteredPolicyScript,
+	updatePolicyScript,
+	updatePoliciesScript,
+	updateFilteredPoliciesScript,
+	loadFilteredPolicyScript,
+	repairScript,
+	savePolicyScript,
+}
+
+// loadScripts loads all scripts into the script cache of the server, or
+// installs the function library if the adapter was created with WithFunctions.
+func (a *Adapter) loadScripts() error {
+	return a.retry(func() error {
+		conn := a.getConn()
+		defer a.release(conn)
+
+		if a.useFunctions {
+			err := loadFunctionLibrary(conn)
+			if err == nil {
+				a.fcall = true
+				return nil
+			}
+			if !isUnknownCommand(err) {
+				return err
+			}
+			// Redis Functions require Redis 7, fall back to EVAL.
+		}
+		return loadLuaScripts(conn)
+	})
+}
+
+func loadLuaScripts(conn redis.Conn) error {
+	for _, s := range luaScripts {
+		if err := s.script.Load(conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalScript invokes the script with EVALSHA. If the script cache was flushed,
+// e.g. by a restart of the server, the scripts are loaded again.
+func (a *Adapter) evalScript(conn redis.Conn, s *luaScript, keysAndArgs ...interface{}) (interface{}, error) {
+	if a.fcall {
+		return callFunction(conn, s, keysAndArgs...)
+	}
+
+	args := redis.Args{}.Add(s.script.Hash(), s.keyCount).Add(keysAndArgs...)
+	reply, err := conn.Do("EVALSHA", args...)
+	if e, ok := err.(redis.Error); ok && strings.HasPrefix(string(e), "NOSCRIPT ") {
+		if err = loadLuaScripts(conn); err != nil {
+			return nil, err
+		}
+		reply, err = conn.Do("EVALSHA", args...)
+	}
+	return reply, err
+}
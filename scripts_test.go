@@ -0,0 +1,70 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func testScriptsLoaded(t *testing.T, a *Adapter) {
+	t.Helper()
+	conn := a.getConn()
+	defer a.release(conn)
+
+	args := redis.Args{}.Add("EXISTS")
+	for _, s := range luaScripts {
+		args = args.Add(s.script.Hash())
+	}
+	exists, err := redis.Ints(conn.Do("SCRIPT", args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range exists {
+		if v != 1 {
+			t.Errorf("Script %d is not loaded", i)
+		}
+	}
+}
+
+func TestScripts(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scripts are loaded on startup.
+	testScriptsLoaded(t, a)
+	initPolicy(t, a)
+
+	// Simulate a restart of the server which flushes the script cache.
+	conn := a.getConn()
+	_, err = conn.Do("SCRIPT", "FLUSH")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scripts are loaded again on NOSCRIPT.
+	if err = a.RemoveFilteredPolicy("p", "p", 0, "data2_admin"); err != nil {
+		t.Fatal(err)
+	}
+	testScriptsLoaded(t, a)
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}})
+}
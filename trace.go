@@ -0,0 +1,86 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The attributes set on the spans of the adapter.
+const (
+	// AttributeKey is the Redis key that stores the rules.
+	AttributeKey = "casbin.redis.key"
+	// AttributePType is the ptype passed to the operation.
+	AttributePType = "casbin.ptype"
+	// AttributeRulesRead is the number of rules received from the server.
+	AttributeRulesRead = "casbin.rules.read"
+	// AttributeRulesWritten is the number of rules inserted, updated or removed.
+	AttributeRulesWritten = "casbin.rules.written"
+	// AttributeScript is the name of the Lua script or function.
+	AttributeScript = "casbin.redis.script"
+	// AttributeCacheHit is whether LoadPolicy was served from the cache.
+	AttributeCacheHit = "casbin.cache.hit"
+)
+
+// Tracer creates a span for every public operation of the adapter and a
+// child span for every script it invokes. The otel package implements it
+// with OpenTelemetry. Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute, value is a string, an int or a bool.
+	SetAttribute(key string, value interface{})
+	// End completes the span with the error of the traced call, if any.
+	End(err error)
+}
+
+// WithTracer makes the adapter trace every operation with t. Methods that
+// take a context.Context start their spans as children of the span in it.
+func WithTracer(t Tracer) Option {
+	return func(a *Adapter) {
+		a.tracer = t
+	}
+}
+
+// setPType records the ptype the operation was called with.
+func (op *operation) setPType(ptype string) {
+	if op.span != nil {
+		op.span.SetAttribute(AttributePType, ptype)
+	}
+}
+
+// setCacheHit records whether the operation was served from the cache.
+func (op *operation) setCacheHit(hit bool) {
+	if op.span != nil {
+		op.span.SetAttribute(AttributeCacheHit, hit)
+	}
+}
+
+// evalScript invokes the script in a child span of the operation.
+func (op *operation) evalScript(conn redis.Conn, s *luaScript, keysAndArgs ...interface{}) (reply interface{}, err error) {
+	if op.span != nil {
+		_, span := op.a.tracer.Start(op.ctx, "script "+s.name)
+		span.SetAttribute(AttributeKey, op.a.key)
+		span.SetAttribute(AttributeScript, s.name)
+		defer func() { span.End(err) }()
+	}
+	return op.a.evalScript(conn, s, keysAndArgs...)
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gomodule/redigo/redis"
+)
+
+// deletedSentinel is the value the scripts temporarily store in place of the
+// rules they remove.
+const deletedSentinel = "__CASBIN_DELETED__"
+
+// repairAttempts is the number of times Repair verifies the rules again when
+// they are modified concurrently.
+const repairAttempts = 3
+
+// ErrConcurrentRepair is returned by Repair when the stored rules keep being
+// modified while it runs.
+var ErrConcurrentRepair = errors.New("rules were modified during repair")
+
+// IssueKind is the kind of problem of a stored rule.
+type IssueKind int
+
+const (
+	// IssueMalformed is a rule that cannot be decoded.
+	IssueMalformed IssueKind = iota
+	// IssueSentinel is a placeholder left by an interrupted script.
+	IssueSentinel
+	// IssueUnknownPType is a rule whose ptype is not defined in the model.
+	IssueUnknownPType
+	// IssueFieldCount is a policy rule whose number of values differs from
+	// the definition of its ptype in the model, or a grouping rule with fewer
+	// values. Empty values are not counted, as they are dropped when the rule
+	// is loaded.
+	IssueFieldCount
+	// IssueDuplicate is a rule that repeats an earlier rule.
+	IssueDuplicate
+)
+
+func (k IssueKind) String() string {
+	switch k {
+	case IssueMalformed:
+		return "malformed"
+	case IssueSentinel:
+		return "sentinel"
+	case IssueUnknownPType:
+		return "unknown ptype"
+	case IssueFieldCount:
+		return "field count mismatch"
+	case IssueDuplicate:
+		return "duplicate"
+	default:
+		return "unknown issue"
+	}
+}
+
+// Issue is a stored rule with a problem.
+type Issue struct {
+	Kind IssueKind
+	// Index is the position of the rule in the list.
+	Index int
+	// Value is the stored rule.
+	Value string
+}
+
+// VerifyReport lists the problems of the stored rules.
+type VerifyReport struct {
+	// Rules is the number of stored rules, including the ones with issues.
+	Rules  int
+	Issues []Issue
+}
+
+// Count returns the number of issues of the given kind.
+func (r *VerifyReport) Count(kind IssueKind) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Verify checks the stored rules against the policy definitions of the model
+// without modifying them.
+func (a *Adapter) Verify(model model.Model) (_ *VerifyReport, err error) {
+	op := a.startOperation("Verify")
+	defer func() { op.end(err) }()
+
+	conn := a.getConn()
+	defer a.release(conn)
+
+	return a.verify(op, conn, model)
+}
+
+func (a *Adapter) verify(op *operation, conn redis.Conn, m model.Model) (*VerifyReport, error) {
+	values, err := redis.Values(conn.Do("LRANGE", a.key, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{Rules: len(values)}
+	seen := make(map[string]struct{})
+	for i, value := range values {
+		text, err := valueToBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		op.addRead(1, len(text))
+
+		kind, ok := checkRule(m, text, seen)
+		if !ok {
+			report.Issues = append(report.Issues, Issue{Kind: kind, Index: i, Value: string(text)})
+		}
+	}
+	return report, nil
+}
+
+// checkRule returns the issue of a stored rule, if it has one. Valid rules
+// are added to seen to detect their duplicates.
+func checkRule(m model.Model, text []byte, seen map[string]struct{}) (IssueKind, bool) {
+	if string(text) == deletedSentinel {
+		return IssueSentinel, false
+	}
+	var line CasbinRule
+	if err := json.Unmarshal(text, &line); err != nil {
+		return IssueMalformed, false
+	}
+	if line.PType == "" || checkSection(line.PType[:1], line.PType) != nil {
+		return IssueUnknownPType, false
+	}
+	ast, ok := m[line.PType[:1]][line.PType]
+	if !ok {
+		return IssueUnknownPType, false
+	}
+	// Casbin accepts grouping rules with more values than the model.
+	values := len(line.toStringPolicy()) - 1
+	if values < len(ast.Tokens) || line.PType[0] == 'p' && values != len(ast.Tokens) {
+		return IssueFieldCount, false
+	}
+	if _, ok := seen[string(text)]; ok {
+		return IssueDuplicate, false
+	}
+	seen[string(text)] = struct{}{}
+	return 0, true
+}
+
+// Repair removes the stored rules reported by Verify and returns the report
+// of the removed rules. The rules are removed atomically, and only if they
+// were not modified since they were verified; otherwise they are verified
+// again.
+func (a *Adapter) Repair(model model.Model) (_ *VerifyReport, err error) {
+	op := a.startOperation("Repair")
+	defer func() { op.end(err) }()
+	a.beginWrite(op)
+
+	conn := a.getConn()
+	defer a.release(conn)
+
+	marker, err := repairMarker()
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < repairAttempts; attempt++ {
+		report, err := a.verify(op, conn, model)
+		if err != nil {
+			return nil, err
+		}
+		if len(report.Issues) == 0 {
+			return report, nil
+		}
+
+		args := redis.Args{}.Add(a.key, a.revisionArg(), marker, report.Rules)
+		for _, issue := range report.Issues {
+			args = args.Add(issue.Index, issue.Value)
+		}
+		repaired, err := redis.Bool(op.evalScript(conn, repairScript, args...))
+		if err != nil {
+			return nil, err
+		}
+		if repaired {
+			op.addWritten(len(report.Issues), 0)
+			return report, nil
+		}
+	}
+	return nil, ErrConcurrentRepair
+}
+
+// repairMarker returns a value that replaces the removed rules. It differs
+// from deletedSentinel so that the leftovers of other scripts are only
+// removed if they were verified.
+func repairMarker() (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	return "__CASBIN_REPAIR_" + token + "__", nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestVerify(t *testing.T) {
+	a, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	initPolicy(t, a)
+
+	args := redis.Args{}.Add(a.key)
+	for _, line := range []CasbinRule{
+		{PType: "p", V0: "alice", V1: "data1", V2: "read"},
+		{PType: "p2", V0: "alice", V1: "data1", V2: "read"},
+		{PType: "r", V0: "alice", V1: "data1", V2: "read"},
+		{PType: "p", V0: "alice", V1: "data1"},
+		{PType: "g", V0: "alice"},
+		// Casbin loads grouping rules with more values than the model.
+		{PType: "g", V0: "bob", V1: "data2_admin", V2: "domain1"},
+	} {
+		text, err := json.Marshal(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = args.Add(text)
+	}
+	args = args.Add(deletedSentinel, "{not json", deletedSentinel)
+	conn := a.getConn()
+	_, err = conn.Do("RPUSH", args...)
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := casbin.NewEnforcer("examples/rbac_model.conf")
+	report, err := a.Verify(e.GetModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rules != 14 || len(report.Issues) != 8 {
+		t.Fatalf("Verify: %d rules, %d issues, supposed to be 14 and 8", report.Rules, len(report.Issues))
+	}
+	for kind, expected := range map[IssueKind]int{
+		IssueDuplicate:    1,
+		IssueUnknownPType: 2,
+		IssueFieldCount:   2,
+		IssueSentinel:     2,
+		IssueMalformed:    1,
+	} {
+		if n := report.Count(kind); n != expected {
+			t.Errorf("%s issues: %d, supposed to be %d", kind, n, expected)
+		}
+	}
+	if issue := report.Issues[0]; issue.Kind != IssueDuplicate || issue.Index != 5 {
+		t.Errorf("First issue: %+v, supposed to be the duplicate at index 5", issue)
+	}
+	testStoredCount(t, a, 14)
+
+	repaired, err := a.Repair(e.GetModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired.Issues) != 8 {
+		t.Errorf("Repair fixed %d issues, supposed to be 8", len(repaired.Issues))
+	}
+	testStoredCount(t, a, 6)
+
+	report, err = a.Verify(e.GetModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rules != 6 || len(report.Issues) != 0 {
+		t.Errorf("Verify after Repair: %d rules, issues %v", report.Rules, report.Issues)
+	}
+	if err = e.InitWithModelAndAdapter(e.GetModel(), a); err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(t, e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}})
+	if !e.HasGroupingPolicy("bob", "data2_admin", "domain1") {
+		t.Error("Repair should keep the grouping rule with more values than the model")
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// PolicyChange is a change queued in write-behind mode.
+type PolicyChange struct {
+	// Remove is true for a removed rule and false for an added one.
+	Remove bool
+	Sec    string
+	PType  string
+	Rule   []string
+}
+
+// WriteBehindError reports queued changes that could not be written. The
+// changes are dropped from the queue.
+type WriteBehindError struct {
+	Changes []PolicyChange
+	Err     error
+}
+
+func (e *WriteBehindError) Error() string {
+	return fmt.Sprintf("failed to write %d queued changes: %v", len(e.Changes), e.Err)
+}
+
+func (e *WriteBehindError) Unwrap() error {
+	return e.Err
+}
+
+// WithWriteBehind makes AddPolicy, AddPolicies, RemovePolicy and
+// RemovePolicies queue their changes and return without waiting for the
+// server. Changes of the same rule are coalesced, only the last one is
+// written. The queue is written in pipelined batches once it holds size
+// rules, or the batch size if size is 0 or less, every interval if it is
+// positive, by Flush and by Close. The other write operations write the
+// queue first, but reads do not see the queued changes until they are
+// written. Changes that cannot be written are passed to onError.
+//
+// The adapter must be closed to write the last changes.
+func WithWriteBehind(size int, interval time.Duration, onError func(*WriteBehindError)) Option {
+	return func(a *Adapter) {
+		a.writeBehind = &writeBehind{
+			size:     size,
+			interval: interval,
+			onError:  onError,
+			changes:  make(map[string]queuedChange),
+			kick:     make(chan struct{}, 1),
+			stop:     make(chan struct{}),
+			stopped:  make(chan struct{}),
+		}
+	}
+}
+
+type queuedChange struct {
+	change PolicyChange
+	text   []byte
+}
+
+// writeBehind is the queue of changes of the write-behind mode.
+type writeBehind struct {
+	size     int
+	interval time.Duration
+	onError  func(*WriteBehindError)
+
+	mu      sync.Mutex
+	order   []string
+	changes map[string]queuedChange
+	started bool
+
+	// flushMu serializes the writes of the queue.
+	flushMu  sync.Mutex
+	kick     chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// start starts writing the queue in the background.
+func (wb *writeBehind) start(a *Adapter) {
+	wb.mu.Lock()
+	if wb.size <= 0 {
+		wb.size = a.batchSize
+	}
+	wb.started = true
+	wb.mu.Unlock()
+
+	go func() {
+		defer close(wb.stopped)
+
+		var tick <-chan time.Time
+		if wb.interval > 0 {
+			ticker := time.NewTicker(wb.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-wb.kick:
+			case <-tick:
+			case <-wb.stop:
+				return
+			}
+			_ = wb.flush(context.Background(), a)
+		}
+	}()
+}
+
+// close stops writing in the background and writes the remaining changes.
+func (wb *writeBehind) close(a *Adapter) error {
+	var err error
+	wb.stopOnce.Do(func() {
+		close(wb.stop)
+		wb.mu.Lock()
+		started := wb.started
+		wb.mu.Unlock()
+		if started {
+			<-wb.stopped
+		}
+		err = wb.flush(context.Background(), a)
+	})
+	return err
+}
+
+// queue adds the changes of the encoded rules to the queue.
+func (wb *writeBehind) queue(a *Adapter, sec string, ptype string, rules [][]string, texts [][]byte, remove bool) error {
+	// Close waits for the changes queued before it to write them.
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closing {
+		return ErrAdapterClosed
+	}
+
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	for i, text := range texts {
+		if _, ok := wb.changes[string(text)]; !ok {
+			wb.order = append(wb.order, string(text))
+		}
+		wb.changes[string(text)] = queuedChange{
+			change: PolicyChange{Remove: remove, Sec: sec, PType: ptype, Rule: rules[i]},
+			text:   text,
+		}
+	}
+	if len(wb.order) >= wb.size {
+		select {
+		case wb.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// take empties the queue and returns its changes.
+func (wb *writeBehind) take() []queuedChange {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	changes := make([]queuedChange, 0, len(wb.order))
+	for _, text := range wb.order {
+		changes = append(changes, wb.changes[text])
+	}
+	wb.order = nil
+	wb.changes = make(map[string]queuedChange)
+	return changes
+}
+
+// flush writes the queued changes, the removals in a transaction and the
+// additions with a script.
+func (wb *writeBehind) flush(ctx context.Context, a *Adapter) (err error) {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+
+	changes := wb.take()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	op := a.startOperationContext(ctx, "Flush")
+	defer func() { op.end(err) }()
+	op.write = true
+
+	var added, removed [][]byte
+	for _, c := range changes {
+		if c.change.Remove {
+			removed = append(removed, c.text)
+		} else {
+			added = append(added, c.text)
+		}
+	}
+
+	// The removals and the checked adds are idempotent, so they can be
+	// retried. An unchecked append whose reply was lost may have been
+	// applied, so it is sent only once.
+	err = a.retry(func() error {
+		conn, err := a.getConnContext(ctx)
+		if err != nil {
+			return err
+		}
+		defer a.release(conn)
+
+		if len(removed) > 0 {
+			if err = a.removeTexts(op, conn, removed); err != nil {
+				return err
+			}
+			removed = nil
+		}
+		if len(added) > 0 && !a.uncheckedAdds {
+			_, err = a.addTexts(op, conn, added)
+		}
+		return err
+	})
+	if err == nil && len(added) > 0 && a.uncheckedAdds {
+		var conn redis.Conn
+		if conn, err = a.getConnContext(ctx); err == nil {
+			err = a.appendTexts(op, conn, added)
+			a.release(conn)
+		}
+	}
+	if err != nil {
+		e := &WriteBehindError{Err: err}
+		for _, c := range changes {
+			e.Changes = append(e.Changes, c.change)
+		}
+		if wb.onError != nil {
+			wb.onError(e)
+		}
+		return e
+	}
+	return nil
+}
+
+// Flush writes the changes queued in write-behind mode and waits for them to
+// be written.
+func (a *Adapter) Flush(ctx context.Context) error {
+	if a.writeBehind == nil {
+		return nil
+	}
+	return a.writeBehind.flush(ctx, a)
+}
+
+// flushWrites writes the queued changes before another write operation, so
+// that the changes are applied in order. Errors are passed to the error
+// handler of the write-behind mode.
+func (a *Adapter) flushWrites() {
+	if a.writeBehind != nil {
+		_ = a.writeBehind.flush(context.Background(), a)
+	}
+}
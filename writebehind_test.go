@@ -0,0 +1,231 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func waitStoredCount(t *testing.T, a *Adapter, expected int) {
+	t.Helper()
+	conn := a.getConn()
+	defer a.release(conn)
+
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		num, err := redis.Int(conn.Do("LLEN", a.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if num == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Stored rules: %d, supposed to be %d", num, expected)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newWriteBehindAdapter(t *testing.T, key string, size int, interval time.Duration, onError func(*WriteBehindError)) *Adapter {
+	t.Helper()
+	a, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:6379"), WithKey(key),
+		WithWriteBehind(size, interval, onError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.ImportCSV(strings.NewReader(""), ImportModeReplace); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestWriteBehind(t *testing.T) {
+	a := newWriteBehindAdapter(t, "casbin_rules_write_behind", 100, 0, nil)
+	ctx := context.Background()
+
+	if err := a.AddPolicies("p", "p", [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddPolicy("p", "p", []string{"carol", "data3", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	// Only the last change of a rule is written.
+	if err := a.RemovePolicy("p", "p", []string{"bob", "data2", "write"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddPolicy("x", "p", []string{"dave", "data4", "read"}); err == nil {
+		t.Error("AddPolicy should fail on an unknown section")
+	}
+	testStoredCount(t, a, 0)
+
+	if err := a.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := a.GetPolicies(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0][1] != "alice" || rules[1][1] != "carol" {
+		t.Errorf("Stored rules: %v, supposed to be the rules of alice and carol", rules)
+	}
+
+	// Other writes are applied after the queued changes.
+	if err = a.RemovePolicy("p", "p", []string{"alice", "data1", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.UpdatePolicy("p", "p", []string{"carol", "data3", "read"}, []string{"carol", "data3", "write"}); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = a.GetPolicies(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0][3] != "write" {
+		t.Errorf("Stored rules: %v, supposed to be the updated rule of carol", rules)
+	}
+
+	// Close writes the last changes.
+	if err = a.AddPolicy("p", "p", []string{"erin", "data5", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.AddPolicy("p", "p", []string{"frank", "data6", "read"}); err != ErrAdapterClosed {
+		t.Errorf("AddPolicy after Close: %v, supposed to be ErrAdapterClosed", err)
+	}
+	b, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_write_behind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	testStoredCount(t, b, 2)
+}
+
+func TestWriteBehindTriggers(t *testing.T) {
+	a := newWriteBehindAdapter(t, "casbin_rules_write_behind_size", 5, 0, nil)
+	defer a.Close()
+	for i := 0; i < 5; i++ {
+		if err := a.AddPolicy("p", "p", []string{fmt.Sprintf("user%d", i), "data1", "read"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitStoredCount(t, a, 5)
+
+	b := newWriteBehindAdapter(t, "casbin_rules_write_behind_interval", 100, 10*time.Millisecond, nil)
+	defer b.Close()
+	if err := b.AddPolicy("p", "p", []string{"alice", "data1", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	waitStoredCount(t, b, 1)
+}
+
+func TestWriteBehindError(t *testing.T) {
+	var mu sync.Mutex
+	var reported []*WriteBehindError
+	a := newWriteBehindAdapter(t, "casbin_rules_write_behind_error", 100, 0, func(e *WriteBehindError) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, e)
+	})
+	defer a.Close()
+
+	// The key holds a string, so the rules cannot be written.
+	conn := a.getConn()
+	_, err := conn.Do("SET", a.key, "not a list")
+	a.release(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		conn := a.getConn()
+		defer a.release(conn)
+		_, _ = conn.Do("DEL", a.key)
+	}()
+
+	if err = a.AddPolicy("p", "p", []string{"alice", "data1", "read"}); err != nil {
+		t.Fatal(err)
+	}
+	err = a.Flush(context.Background())
+	var wbErr *WriteBehindError
+	if !errors.As(err, &wbErr) || len(wbErr.Changes) != 1 || wbErr.Changes[0].Rule[0] != "alice" {
+		t.Fatalf("Flush: %v, supposed to report the change of alice", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || reported[0] != wbErr {
+		t.Errorf("Reported errors: %v, supposed to be the error of Flush", reported)
+	}
+}
+
+func TestWriteBehindClose(t *testing.T) {
+	a := newWriteBehindAdapter(t, "casbin_rules_write_behind_close", 10, time.Millisecond, nil)
+
+	// Every change that was queued successfully is written by Close.
+	var wg sync.WaitGroup
+	var queued int64
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := a.AddPolicy("p", "p", []string{fmt.Sprintf("user%d_%d", i, j), "data1", "read"})
+				if err == ErrAdapterClosed {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				atomic.AddInt64(&queued, 1)
+			}
+		}(i)
+	}
+	time.Sleep(time.Millisecond)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	b, err := NewAdapterWithKey("tcp", "127.0.0.1:6379", "casbin_rules_write_behind_close")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	testStoredCount(t, b, int(atomic.LoadInt64(&queued)))
+
+	// The workers are not started if the adapter cannot be created.
+	c, err := NewAdapterWithOption(WithNetwork("tcp"), WithAddress("127.0.0.1:1"), WithRetry(0, 0, 0),
+		WithWriteBehind(10, time.Millisecond, nil), WithCache(CacheKeyspaceNotifications))
+	if err == nil {
+		t.Fatal("NewAdapterWithOption should fail to connect")
+	}
+	if c.writeBehind.started || c.cache.stop != nil {
+		t.Error("The workers should not be started")
+	}
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+}